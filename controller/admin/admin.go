@@ -4,8 +4,10 @@ import (
 	"crypto/rand"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"log"
 	"walk-server/global"
 	"walk-server/model"
+	"walk-server/service/adminService"
 	"walk-server/utility"
 )
 
@@ -20,7 +22,6 @@ type CreateRouteAdminData struct {
 	PFAll   [][]Data `json:"pf_all"`
 	MGSHalf [][]Data `json:"mgs_half"`
 	MGSAll  [][]Data `json:"mgs_all"`
-	Secret  string   `json:"secret" binding:"required"`
 }
 
 const (
@@ -34,37 +35,41 @@ func CreateRouteAdmin(c *gin.Context) {
 		return
 	}
 
-	if postForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
-		return
-	}
-
 	admins := make([]model.Admin, 0)
+	passwords := make([]string, 0) // 生成的明文密码只在本次响应中返回，数据库只保存哈希
 
-	processData := func(data [][]Data, point int8) {
+	processData := func(data [][]Data, point int8) error {
 		for i := 0; i < len(data); i++ {
 			for j := 0; j < len(data[i]); j++ {
 				pwd, err := generateRandomPassword()
 				if err != nil {
-					utility.ResponseError(c, "密码生成错误: "+err.Error())
-					return
+					return err
+				}
+				hash, err := utility.HashPassword(pwd)
+				if err != nil {
+					return err
 				}
 				admins = append(admins, model.Admin{
-					Name:     data[i][j].Name,
-					Account:  data[i][j].Account,
-					Password: pwd,
-					Point:    point,
-					Route:    uint8(j),
+					Name:               data[i][j].Name,
+					Account:            data[i][j].Account,
+					Password:           hash,
+					Point:              point,
+					Route:              uint8(j),
+					Role:               model.RoleStaff,
+					MustChangePassword: true,
 				})
+				passwords = append(passwords, pwd)
 			}
 		}
+		return nil
 	}
 
-	processData(postForm.ZH, 1)
-	processData(postForm.PFHalf, 2)
-	processData(postForm.PFAll, 3)
-	processData(postForm.MGSHalf, 4)
-	processData(postForm.MGSAll, 5)
+	for point, data := range [][][]Data{postForm.ZH, postForm.PFHalf, postForm.PFAll, postForm.MGSHalf, postForm.MGSAll} {
+		if err := processData(data, int8(point+1)); err != nil {
+			utility.ResponseError(c, "密码生成错误: "+err.Error())
+			return
+		}
+	}
 
 	result := global.DB.Create(&admins)
 	if result.Error != nil {
@@ -72,11 +77,58 @@ func CreateRouteAdmin(c *gin.Context) {
 		return
 	}
 
+	var adminData []gin.H
+	for i, admin := range admins {
+		adminData = append(adminData, gin.H{
+			"admin_id": admin.ID,
+			"name":     admin.Name,
+			"account":  admin.Account,
+			"password": passwords[i],
+			"point":    admin.Point,
+			"route":    admin.Route,
+		})
+	}
+
 	utility.ResponseSuccess(c, gin.H{
-		"admins": admins,
+		"admins": adminData,
 	})
 }
 
+type SetAdminRoleForm struct {
+	AdminID uint  `json:"admin_id" binding:"required"`
+	Role    uint8 `json:"role" binding:"required,oneof=1 2 3"` // 1 超级管理员，2 路线负责人，3 点位工作人员
+}
+
+// SetAdminRole 修改管理员角色
+func SetAdminRole(c *gin.Context) {
+	var postForm SetAdminRoleForm
+	if err := c.ShouldBindJSON(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+
+	user, _ := adminService.GetAdminByJWT(c)
+	if user.ID == postForm.AdminID {
+		utility.ResponseError(c, "不能修改自己的角色")
+		return
+	}
+	if _, err := adminService.GetAdminByID(postForm.AdminID); err != nil {
+		utility.ResponseError(c, "管理员不存在")
+		return
+	}
+
+	if err := adminService.UpdateRole(postForm.AdminID, postForm.Role); err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+	// 角色保存在 token 中，需要重新登录后生效
+	if err := adminService.RevokeAllSessions(postForm.AdminID); err != nil {
+		log.Println(err)
+	}
+	utility.ResponseSuccess(c, nil)
+}
+
 // generateRandomString 生成一个指定长度的随机字符串，使用字母和数字
 func generateRandomString(n int) (string, error) {
 	// 创建一个字节切片来存储随机字节
@@ -96,5 +148,5 @@ func generateRandomString(n int) (string, error) {
 
 // generateRandomPassword 生成一个随机密码
 func generateRandomPassword() (string, error) {
-	return generateRandomString(6) // 6 个随机字母数字字符
+	return generateRandomString(10) // 10 个随机字母数字字符
 }
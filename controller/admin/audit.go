@@ -0,0 +1,148 @@
+package admin
+
+import (
+	"log"
+	"time"
+	"walk-server/model"
+	"walk-server/service/routeService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditLogsForm struct {
+	AdminID  uint   `form:"admin_id"`
+	Route    uint8  `form:"route"`
+	Point    *int8  `form:"point"`
+	TeamID   uint   `form:"team_id"`
+	Start    string `form:"start"` // 格式为 2006-01-02 15:04:05
+	End      string `form:"end"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size" binding:"max=200"`
+}
+
+// parseAuditFilter 解析查询条件，时间格式错误时返回 false
+func parseAuditFilter(c *gin.Context, postForm *AuditLogsForm) (model.AuditFilter, bool) {
+	filter := model.AuditFilter{
+		AdminID: postForm.AdminID,
+		Route:   postForm.Route,
+		Point:   postForm.Point,
+		TeamID:  postForm.TeamID,
+	}
+	var err error
+	if postForm.Start != "" {
+		if filter.Start, err = time.ParseInLocation(time.DateTime, postForm.Start, time.Local); err != nil {
+			utility.ResponseError(c, "时间格式错误")
+			return filter, false
+		}
+	}
+	if postForm.End != "" {
+		if filter.End, err = time.ParseInLocation(time.DateTime, postForm.End, time.Local); err != nil {
+			utility.ResponseError(c, "时间格式错误")
+			return filter, false
+		}
+	}
+	return filter, true
+}
+
+// GetAuditLogs 分页查询管理端接口调用记录
+func GetAuditLogs(c *gin.Context) {
+	var postForm AuditLogsForm
+	if err := c.ShouldBindQuery(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+	filter, ok := parseAuditFilter(c, &postForm)
+	if !ok {
+		return
+	}
+	if postForm.Page <= 0 {
+		postForm.Page = 1
+	}
+	if postForm.PageSize <= 0 {
+		postForm.PageSize = 50
+	}
+
+	logs, total, err := model.GetAuditLogs(filter, (postForm.Page-1)*postForm.PageSize, postForm.PageSize)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"logs":  logs,
+		"total": total,
+	})
+}
+
+// maxAuditExportRows 单次导出的最大行数
+const maxAuditExportRows = 50000
+
+// ExportAuditLogs 按条件导出调用记录
+func ExportAuditLogs(c *gin.Context) {
+	var postForm AuditLogsForm
+	if err := c.ShouldBindQuery(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+	filter, ok := parseAuditFilter(c, &postForm)
+	if !ok {
+		return
+	}
+
+	logs, total, err := model.GetAuditLogs(filter, 0, maxAuditExportRows)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+	if len(logs) == 0 {
+		utility.ResponseError(c, "没有符合条件的记录")
+		return
+	}
+
+	headers := []string{"时间", "管理员ID", "管理员", "路线", "点位", "方法", "接口", "队伍ID", "用户", "返回码", "返回信息", "耗时(毫秒)", "IP", "请求参数"}
+	rows := make([][]any, 0, len(logs))
+	for _, auditLog := range logs {
+		rows = append(rows, []any{
+			auditLog.Time.Format(time.DateTime),
+			auditLog.AdminID,
+			auditLog.AdminName,
+			routeService.GetRouteName(auditLog.Route),
+			routeService.GetPointName(auditLog.Route, auditLog.Point),
+			auditLog.Method,
+			auditLog.Endpoint,
+			auditLog.TeamID,
+			auditLog.PersonID,
+			auditLog.Code,
+			auditLog.Message,
+			auditLog.Latency,
+			auditLog.IP,
+			auditLog.Request,
+		})
+	}
+
+	data := utility.File{
+		Sheets: []utility.Sheet{{
+			Name:    "操作记录",
+			Headers: headers,
+			Rows:    rows,
+		}},
+	}
+
+	// 保存为 Excel 文件
+	fileName := "操作记录" + time.Now().Format("20060102150405") + ".xlsx"
+	url, err := utility.CreateExcelFile(data, fileName)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "生成文件失败")
+		return
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"url":   url,
+		"total": total,
+		"count": len(logs), // 超过导出上限时只导出最近的记录
+	})
+}
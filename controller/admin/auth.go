@@ -5,10 +5,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"log"
-	"strconv"
-	"walk-server/constant"
 	"walk-server/global"
+	"walk-server/model"
 	"walk-server/service/adminService"
+	"walk-server/service/routeService"
 	"walk-server/utility"
 )
 
@@ -28,13 +28,38 @@ type LoginForm struct {
 }
 
 type LoginResp struct {
-	ID           uint   `json:"admin_id"`
-	WechatOpenID string `json:"-"`
-	Name         string `json:"name"`
-	Account      string `json:"account"`
-	Password     string `json:"-"`
-	Point        string `json:"point"`
-	Route        uint8  `json:"route"`
+	ID                 uint   `json:"admin_id"`
+	WechatOpenID       string `json:"-"`
+	Name               string `json:"name"`
+	Account            string `json:"account"`
+	Password           string `json:"-"`
+	Point              string `json:"point"`
+	Route              uint8  `json:"route"`
+	Role               uint8  `json:"role"`
+	MustChangePassword bool   `json:"must_change_password"` // 为 true 时需要先修改密码才能使用其他功能
+}
+
+// checkAccount 校验账号和密码，失败时已写入响应
+// 账号不存在时同样进行一次 bcrypt 比较并返回相同的提示，避免通过提示或耗时判断账号是否存在
+func checkAccount(c *gin.Context, account string, password string) (*model.Admin, bool) {
+	user, err := adminService.GetUserByAccount(account)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return nil, false
+	}
+
+	var matched bool
+	if err == nil && user.Password != "" {
+		matched = utility.CheckPassword(user.Password, password)
+	} else {
+		matched = utility.CheckDummyPassword(password)
+	}
+	if !matched {
+		utility.ResponseError(c, "账号或密码错误")
+		return nil, false
+	}
+	return user, true
 }
 
 func AuthByPassword(c *gin.Context) {
@@ -45,19 +70,8 @@ func AuthByPassword(c *gin.Context) {
 		utility.ResponseError(c, "参数错误")
 		return
 	}
-	user, err := adminService.GetUserByAccount(postForm.Username)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		utility.ResponseError(c, "账号错误")
-		return
-	}
-	if err != nil {
-		log.Println(err)
-		utility.ResponseError(c, "服务错误")
-		return
-	}
-
-	if user.Password != postForm.Password {
-		utility.ResponseError(c, "密码错误")
+	user, ok := checkAccount(c, postForm.Username, postForm.Password)
+	if !ok {
 		return
 	}
 
@@ -70,22 +84,7 @@ func AuthByPassword(c *gin.Context) {
 		user.WechatOpenID = session.OpenID
 		adminService.UpdateOpenID(user)
 	}
-	var jwtData utility.JwtData
-	jwtData.OpenID = utility.AesEncrypt(strconv.Itoa(int(user.ID)), global.Config.GetString("server.AESSecret"))
-	// 生成 JWT
-	jwtToken, err := utility.GenerateStandardJwt(&jwtData)
-
-	utility.ResponseSuccess(c, gin.H{
-		"admin": LoginResp{
-			ID:           user.ID,
-			WechatOpenID: user.WechatOpenID,
-			Name:         user.Name,
-			Account:      user.Account,
-			Point:        constant.GetPointName(user.Route, user.Point),
-			Route:        user.Route,
-		},
-		"jwt": jwtToken,
-	})
+	respondLogin(c, user)
 }
 
 func WeChatLogin(c *gin.Context) {
@@ -109,22 +108,7 @@ func WeChatLogin(c *gin.Context) {
 		return
 	}
 
-	var jwtData utility.JwtData
-	jwtData.OpenID = utility.AesEncrypt(strconv.Itoa(int(user.ID)), global.Config.GetString("server.AESSecret"))
-	// 生成 JWT
-	jwtToken, err := utility.GenerateStandardJwt(&jwtData)
-
-	utility.ResponseSuccess(c, gin.H{
-		"admin": LoginResp{
-			ID:           user.ID,
-			WechatOpenID: user.WechatOpenID,
-			Name:         user.Name,
-			Account:      user.Account,
-			Point:        constant.GetPointName(user.Route, user.Point),
-			Route:        user.Route,
-		},
-		"jwt": jwtToken,
-	})
+	respondLogin(c, user)
 }
 
 func AuthWithoutCode(c *gin.Context) {
@@ -135,52 +119,85 @@ func AuthWithoutCode(c *gin.Context) {
 		utility.ResponseError(c, "参数错误")
 		return
 	}
-	user, err := adminService.GetUserByAccount(postForm.Username)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		utility.ResponseError(c, "账号错误")
+	user, ok := checkAccount(c, postForm.Username, postForm.Password)
+	if !ok {
 		return
 	}
+
+	respondLogin(c, user)
+}
+
+// GetPermission 获取当前管理员的角色和权限，用于前端控制可用的功能
+func GetPermission(c *gin.Context) {
+	user, _ := adminService.GetAdminByJWT(c)
+	utility.ResponseSuccess(c, gin.H{
+		"role":                 user.Role,
+		"permissions":          adminService.GetPermissions(user.Role),
+		"must_change_password": user.MustChangePassword,
+	})
+}
+
+// respondLogin 为管理员创建登录会话并返回 token
+func respondLogin(c *gin.Context, user *model.Admin) {
+	c.Set("admin", user) // 供调用记录使用
+	tokens, err := adminService.Login(user, c.GetHeader("User-Agent"), c.ClientIP())
 	if err != nil {
 		log.Println(err)
 		utility.ResponseError(c, "服务错误")
 		return
 	}
 
-	if user.Password != postForm.Password {
-		utility.ResponseError(c, "密码错误")
-		return
-	}
-
-	var jwtData utility.JwtData
-	jwtData.OpenID = utility.AesEncrypt(strconv.Itoa(int(user.ID)), global.Config.GetString("server.AESSecret"))
-	// 生成 JWT
-	jwtToken, err := utility.GenerateStandardJwt(&jwtData)
 	utility.ResponseSuccess(c, gin.H{
 		"admin": LoginResp{
-			ID:           user.ID,
-			WechatOpenID: user.WechatOpenID,
-			Name:         user.Name,
-			Account:      user.Account,
-			Point:        constant.GetPointName(user.Route, user.Point),
-			Route:        user.Route,
+			ID:                 user.ID,
+			WechatOpenID:       user.WechatOpenID,
+			Name:               user.Name,
+			Account:            user.Account,
+			Point:              routeService.GetPointName(user.Route, user.Point),
+			Route:              user.Route,
+			Role:               user.Role,
+			MustChangePassword: user.MustChangePassword,
 		},
-		"jwt": jwtToken,
+		"jwt":           tokens.Access,
+		"refresh_token": tokens.Refresh,
+		"expires_at":    tokens.ExpiresAt,
 	})
 }
 
-type BlockWithSecretForm struct {
-	Secret string `json:"secret" binding:"required"`
+type RefreshTokenForm struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
-func BlockWithSecret(c *gin.Context) {
-	var postForm BlockWithSecretForm
-	err := c.ShouldBindJSON(&postForm)
-	if err != nil {
+// RefreshToken 使用 refresh token 换取新的 token
+func RefreshToken(c *gin.Context) {
+	var postForm RefreshTokenForm
+	if err := c.ShouldBindJSON(&postForm); err != nil {
 		utility.ResponseError(c, "参数错误")
 		return
 	}
-	if postForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
+
+	user, tokens, err := adminService.Refresh(postForm.RefreshToken)
+	if err != nil {
+		utility.ResponseError(c, "登录已失效，请重新登录")
+		return
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"role":          user.Role,
+		"jwt":           tokens.Access,
+		"refresh_token": tokens.Refresh,
+		"expires_at":    tokens.ExpiresAt,
+	})
+}
+
+// Logout 退出登录，吊销当前会话
+func Logout(c *gin.Context) {
+	claims, _ := c.Get("claims")
+	sid := claims.(*utility.AdminClaims).SessionID
+	user, _ := adminService.GetAdminByJWT(c)
+	if _, err := adminService.RevokeSession(user.ID, sid); err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
 		return
 	}
 	utility.ResponseSuccess(c, nil)
@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"errors"
+	"log"
+	"walk-server/model"
+	"walk-server/service/adminService"
+	"walk-server/service/broadcastService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type BroadcastForm struct {
+	Message string                `json:"message" binding:"required,max=1000"`
+	Channel string                `json:"channel" binding:"omitempty,oneof=wechat wechat_template sms inapp"` // 推送渠道，为空时使用默认渠道
+	Filter  model.BroadcastFilter `json:"filter"`
+	Preview bool                  `json:"preview"` // 为 true 时只返回接收人，不发送
+}
+
+// checkBroadcastFilter 限制非超级管理员只能向自己管理的路线发送广播
+func checkBroadcastFilter(c *gin.Context, filter *model.BroadcastFilter) bool {
+	user, _ := adminService.GetAdminByJWT(c)
+	if user.Role != model.RoleSuper {
+		if len(filter.Routes) == 0 {
+			filter.Routes = []uint8{user.Route}
+		}
+		for _, route := range filter.Routes {
+			if !adminService.CanManageRoute(user, route) {
+				utility.ResponseError(c, "没有管理该路线的权限")
+				return false
+			}
+		}
+	}
+
+	if len(filter.Routes) == 0 && filter.PointFrom == nil && filter.PointTo == nil &&
+		len(filter.Statuses) == 0 && filter.IsLost == nil && len(filter.TeamIDs) == 0 {
+		utility.ResponseError(c, "请至少设置一个接收条件")
+		return false
+	}
+	return true
+}
+
+// SendBroadcast 按路线、点位范围、队伍状态、失联状态或队伍编号向队伍成员发送广播
+func SendBroadcast(c *gin.Context) {
+	var postForm BroadcastForm
+	if err := c.ShouldBindJSON(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+	if !checkBroadcastFilter(c, &postForm.Filter) {
+		return
+	}
+
+	if postForm.Preview {
+		persons, err := broadcastService.SelectRecipients(postForm.Filter)
+		if err != nil {
+			log.Println(err)
+			utility.ResponseError(c, "服务错误")
+			return
+		}
+		recipients := make([]gin.H, 0, len(persons))
+		for _, person := range persons {
+			recipients = append(recipients, gin.H{
+				"team_id": person.TeamId,
+				"open_id": person.OpenId,
+				"name":    person.Name,
+			})
+		}
+		utility.ResponseSuccess(c, gin.H{
+			"total":      len(recipients),
+			"recipients": recipients,
+		})
+		return
+	}
+
+	user, _ := adminService.GetAdminByJWT(c)
+	broadcast, err := broadcastService.Send(user, postForm.Message, postForm.Channel, postForm.Filter)
+	if errors.Is(err, broadcastService.ErrNoRecipient) {
+		utility.ResponseError(c, "没有符合条件的接收人")
+		return
+	} else if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"broadcast": broadcast,
+	})
+}
+
+type BroadcastListForm struct {
+	Page     int `form:"page"`
+	PageSize int `form:"page_size" binding:"max=100"`
+}
+
+// GetBroadcasts 获取广播列表，非超级管理员只能查看自己发送的广播
+func GetBroadcasts(c *gin.Context) {
+	var postForm BroadcastListForm
+	if err := c.ShouldBindQuery(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+	if postForm.Page <= 0 {
+		postForm.Page = 1
+	}
+	if postForm.PageSize <= 0 {
+		postForm.PageSize = 20
+	}
+
+	user, _ := adminService.GetAdminByJWT(c)
+	adminID := user.ID
+	if user.Role == model.RoleSuper {
+		adminID = 0
+	}
+	broadcasts, total, err := model.GetBroadcasts(adminID, (postForm.Page-1)*postForm.PageSize, postForm.PageSize)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"broadcasts": broadcasts,
+		"total":      total,
+	})
+}
+
+type BroadcastDetailForm struct {
+	ID     uint  `form:"id" binding:"required"`
+	Status uint8 `form:"status" binding:"omitempty,oneof=1 2 3"` // 1 等待发送，2 成功，3 失败，为空时获取全部
+}
+
+// GetBroadcastDetail 获取广播每个接收人的发送结果
+func GetBroadcastDetail(c *gin.Context) {
+	var postForm BroadcastDetailForm
+	if err := c.ShouldBindQuery(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+
+	broadcast, err := model.GetBroadcast(postForm.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utility.ResponseError(c, "广播不存在")
+		return
+	} else if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+	user, _ := adminService.GetAdminByJWT(c)
+	if user.Role != model.RoleSuper && broadcast.AdminID != user.ID {
+		utility.ResponseError(c, "没有权限")
+		return
+	}
+
+	receipts, err := model.GetReceipts(broadcast.ID, postForm.Status)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"broadcast": broadcast,
+		"receipts":  receipts,
+	})
+}
+
+type DeadNotificationsForm struct {
+	Limit int64 `form:"limit" binding:"max=1000"`
+}
+
+// GetDeadNotifications 获取最近推送失败且不再重试的通知
+func GetDeadNotifications(c *gin.Context) {
+	var postForm DeadNotificationsForm
+	if err := c.ShouldBindQuery(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+	if postForm.Limit <= 0 {
+		postForm.Limit = 100
+	}
+	// 死信包含全部用户的消息，只允许超级管理员查看
+	if user, _ := adminService.GetAdminByJWT(c); user.Role != model.RoleSuper {
+		utility.ResponseError(c, "没有权限")
+		return
+	}
+
+	notifications, err := utility.GetDeadNotifications(postForm.Limit)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+	utility.ResponseSuccess(c, gin.H{
+		"notifications": notifications,
+	})
+}
@@ -0,0 +1,181 @@
+package admin
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"walk-server/global"
+	"walk-server/middleware"
+	"walk-server/model"
+	"walk-server/service/adminService"
+	"walk-server/service/routeService"
+	"walk-server/service/teamService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordCheckpoint 记录一次队伍状态变化并通知看板，记录失败不影响扫码结果
+// prev 为扫码前的队伍，members 为本次扫码改变了行进状态的成员，用于撤销打卡
+func recordCheckpoint(admin *model.Admin, prev model.Team, team *model.Team, num uint, members []model.MemberWalkStatus) {
+	err := model.InsertCheckpoint(&model.Checkpoint{
+		TeamID:         team.ID,
+		AdminID:        admin.ID,
+		Route:          team.Route,
+		PrevPoint:      prev.Point,
+		Point:          team.Point,
+		PrevStatus:     prev.Status,
+		Status:         team.Status,
+		Num:            num,
+		Time:           team.Time,
+		PrevTime:       prev.Time,
+		PrevLost:       prev.IsLost,
+		PrevLostReason: prev.LostReason,
+		PrevCode:       prev.Code,
+		PrevStartNum:   prev.StartNum,
+		Members:        members,
+	})
+	if err != nil {
+		log.Println(err)
+	}
+	adminService.NotifyDetailChanged(team.Route)
+}
+
+type TeamTimelineForm struct {
+	TeamID uint `form:"team_id" binding:"required"`
+}
+
+// GetTeamTimeline 获取队伍的完整打卡记录
+func GetTeamTimeline(c *gin.Context) {
+	var postForm TeamTimelineForm
+	if err := c.ShouldBindQuery(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+
+	user, _ := adminService.GetAdminByJWT(c)
+	team, err := teamService.GetTeamByID(postForm.TeamID)
+	if team == nil || err != nil {
+		utility.ResponseError(c, "队伍查找失败，请重新核对")
+		return
+	}
+
+	if !middleware.CheckRoute(user, team) {
+		utility.ResponseError(c, "该队伍为其他路线")
+		return
+	}
+
+	checkpoints, err := model.GetCheckpoints(team.ID)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	// 批量查询扫码的管理员
+	adminIDs := make([]uint, 0, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		adminIDs = append(adminIDs, checkpoint.AdminID)
+	}
+	var admins []model.Admin
+	if len(adminIDs) > 0 {
+		global.DB.Where("id IN ?", adminIDs).Find(&admins)
+	}
+	adminMap := make(map[uint]model.Admin, len(admins))
+	for _, admin := range admins {
+		adminMap[admin.ID] = admin
+	}
+
+	timeline := make([]gin.H, 0, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		timeline = append(timeline, gin.H{
+			"id":          checkpoint.ID,
+			"point":       checkpoint.Point,
+			"location":    routeService.GetPointName(checkpoint.Route, checkpoint.Point),
+			"prev_point":  checkpoint.PrevPoint,
+			"status":      checkpoint.Status,
+			"prev_status": checkpoint.PrevStatus,
+			"num":         checkpoint.Num,
+			"time":        checkpoint.Time,
+			"members":     checkpoint.Members,
+			"reverted":    checkpoint.Reverted,
+			"reverted_by": checkpoint.RevertedBy,
+			"reason":      checkpoint.RevertReason,
+			"reverted_at": checkpoint.RevertedAt,
+			"admin": gin.H{
+				"admin_id": checkpoint.AdminID,
+				"name":     adminMap[checkpoint.AdminID].Name,
+				"route":    adminMap[checkpoint.AdminID].Route,
+				"point":    adminMap[checkpoint.AdminID].Point,
+			},
+		})
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"team": gin.H{
+			"id":     team.ID,
+			"name":   team.Name,
+			"route":  team.Route,
+			"point":  team.Point,
+			"status": team.Status,
+		},
+		"timeline": timeline,
+	})
+}
+
+type RevertCheckpointForm struct {
+	TeamID       uint   `json:"team_id" binding:"required"`
+	CheckpointID uint   `json:"checkpoint_id" binding:"required"` // 必须是队伍最后一条未撤销的打卡记录
+	Reason       string `json:"reason" binding:"required,max=255"`
+}
+
+// RevertCheckpoint 撤销队伍最后一次打卡，用于修正扫错队伍或误点未完成
+func RevertCheckpoint(c *gin.Context) {
+	var postForm RevertCheckpointForm
+	if err := c.ShouldBindJSON(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+	if strings.TrimSpace(postForm.Reason) == "" {
+		utility.ResponseError(c, "请填写撤销原因")
+		return
+	}
+
+	team, err := teamService.GetTeamByID(postForm.TeamID)
+	if team == nil || err != nil {
+		utility.ResponseError(c, "队伍查找失败，请重新核对")
+		return
+	}
+	middleware.SetAuditTeam(c, team.ID)
+	if !checkManageRoute(c, team.Route) {
+		return
+	}
+
+	user, _ := adminService.GetAdminByJWT(c)
+	team, checkpoint, err := teamService.RevertCheckpoint(team.ID, postForm.CheckpointID, user.ID, postForm.Reason)
+	switch {
+	case errors.Is(err, teamService.ErrNoCheckpoint):
+		utility.ResponseError(c, "没有可以撤销的打卡记录")
+		return
+	case errors.Is(err, teamService.ErrCheckpointOutdated):
+		utility.ResponseError(c, "只能撤销最后一次打卡")
+		return
+	case errors.Is(err, teamService.ErrTeamChanged), errors.Is(err, teamService.ErrMemberChanged):
+		utility.ResponseError(c, "打卡后队伍状态已变化，无法撤销")
+		return
+	case errors.Is(err, teamService.ErrCheckpointLegacy):
+		utility.ResponseError(c, "该记录缺少打卡前的状态，无法撤销")
+		return
+	case err != nil:
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	adminService.NotifyDetailChanged(team.Route)
+	utility.ResponseSuccess(c, gin.H{
+		"checkpoint": checkpoint,
+		"point":      team.Point,
+		"status":     team.Status,
+	})
+}
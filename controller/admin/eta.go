@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"log"
+	"time"
+	"walk-server/service/adminService"
+	"walk-server/service/routeService"
+	"walk-server/service/teamService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ETAForm struct {
+	Route  uint8 `form:"route"`                           // 为空时返回可以管理的全部路线
+	Point  *int8 `form:"point"`                           // 为空时返回全部点位
+	Within int   `form:"within" binding:"min=0,max=1440"` // 只返回该分钟数内预计到达的队伍（包括已超时的队伍），为 0 时不限
+}
+
+// GetETA 获取各路线各点位预计到达的队伍
+func GetETA(c *gin.Context) {
+	var postForm ETAForm
+	if err := c.ShouldBindQuery(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+
+	user, _ := adminService.GetAdminByJWT(c)
+	var routes []uint8
+	if postForm.Route != 0 {
+		if !routeService.Exists(postForm.Route) {
+			utility.ResponseError(c, "路线不存在")
+			return
+		}
+		if !checkManageRoute(c, postForm.Route) {
+			return
+		}
+		routes = append(routes, postForm.Route)
+	} else {
+		for _, r := range routeService.GetRoutes() {
+			if adminService.CanManageRoute(user, r.ID) {
+				routes = append(routes, r.ID)
+			}
+		}
+	}
+
+	deadline := time.Now().Add(time.Duration(postForm.Within) * time.Minute)
+	result := make([]teamService.PointArrivals, 0)
+	for _, route := range routes {
+		points, err := teamService.PredictArrivals(route)
+		if err != nil {
+			log.Println(err)
+			utility.ResponseError(c, "服务错误")
+			return
+		}
+		for _, point := range points {
+			if postForm.Point != nil && point.Point != *postForm.Point {
+				continue
+			}
+			if postForm.Within > 0 {
+				arrivals := point.Arrivals[:0]
+				for _, arrival := range point.Arrivals {
+					if !arrival.ETA.After(deadline) {
+						arrivals = append(arrivals, arrival)
+					}
+				}
+				point.Arrivals = arrivals
+			}
+			result = append(result, point)
+		}
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"points": result,
+		"time":   time.Now(),
+	})
+}
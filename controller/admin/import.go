@@ -0,0 +1,96 @@
+package admin
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"walk-server/service/userService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportFileSize 导入文件的大小上限
+const maxImportFileSize = 10 << 20
+
+// ImportAlumni 上传 xlsx 表格批量导入校友，返回每一行的错误和可下载的错误报告
+func ImportAlumni(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utility.ResponseError(c, "请上传文件")
+		return
+	}
+	if !strings.HasSuffix(strings.ToLower(fileHeader.Filename), ".xlsx") {
+		utility.ResponseError(c, "仅支持 xlsx 格式的文件")
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		utility.ResponseError(c, "文件不能超过 10MB")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+	defer file.Close()
+
+	result, err := userService.ImportAlumni(file)
+	if errors.Is(err, userService.ErrImportEmpty) || errors.Is(err, userService.ErrImportTooMany) {
+		utility.ResponseError(c, err.Error())
+		return
+	} else if errors.Is(err, userService.ErrImportMissColumn) {
+		utility.ResponseError(c, "表头需要包含"+strings.Join(userService.ImportColumns[:5], "、")+"列")
+		return
+	} else if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "文件解析或导入失败，请检查后重试")
+		return
+	}
+
+	// 有失败的行时生成错误报告
+	url := ""
+	if len(result.Failed) > 0 {
+		url, err = createImportReport(result.Failed)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"total":    result.Total,
+		"imported": result.Imported,
+		"failed":   result.Failed,
+		"url":      url,
+	})
+}
+
+// createImportReport 将导入失败的行保存为 Excel 文件
+func createImportReport(failed []userService.ImportRow) (string, error) {
+	headers := append([]string{"行号"}, userService.ImportColumns...)
+	headers = append(headers, "错误原因")
+	rows := make([][]any, 0, len(failed))
+	for _, row := range failed {
+		line := []any{strconv.Itoa(row.Row)}
+		for _, value := range row.Values {
+			line = append(line, value)
+		}
+		line = append(line, strings.Join(row.Errors, "；"))
+		rows = append(rows, line)
+	}
+
+	data := utility.File{
+		Sheets: []utility.Sheet{{
+			Name:    "导入错误",
+			Headers: headers,
+			Rows:    rows,
+		}},
+	}
+
+	fileName := "导入错误" + time.Now().Format("20060102150405") + ".xlsx"
+	return utility.CreateExcelFile(data, fileName)
+}
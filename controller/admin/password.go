@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"log"
+	"walk-server/service/adminService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChangePasswordForm struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=64"`
+}
+
+// ChangePassword 管理员修改自己的密码，首次登录或密码被重置后必须先修改密码，成功后返回新的 token
+func ChangePassword(c *gin.Context) {
+	var postForm ChangePasswordForm
+	if err := c.ShouldBindJSON(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+
+	current, _ := adminService.GetAdminByJWT(c)
+	user, err := adminService.GetAdminByID(current.ID)
+	if err != nil {
+		utility.ResponseError(c, "管理员不存在")
+		return
+	}
+	if !utility.CheckPassword(user.Password, postForm.OldPassword) {
+		utility.ResponseError(c, "密码错误")
+		return
+	}
+	if postForm.NewPassword == postForm.OldPassword {
+		utility.ResponseError(c, "新密码不能与原密码相同")
+		return
+	}
+
+	if err := adminService.SetPassword(user.ID, postForm.NewPassword, false); err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	// 修改密码后其他设备需要重新登录，当前设备直接下发新的 token
+	if err := adminService.RevokeAllSessions(user.ID); err != nil {
+		log.Println(err)
+	}
+	user.MustChangePassword = false
+	respondLogin(c, user)
+}
+
+type ResetPasswordForm struct {
+	AdminID uint `json:"admin_id" binding:"required"`
+}
+
+// ResetPassword 重置管理员密码，新密码只在本次响应中返回，对方登录后需要修改密码
+func ResetPassword(c *gin.Context) {
+	var postForm ResetPasswordForm
+	if err := c.ShouldBindJSON(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+
+	target, err := adminService.GetAdminByID(postForm.AdminID)
+	if err != nil {
+		utility.ResponseError(c, "管理员不存在")
+		return
+	}
+
+	pwd, err := generateRandomPassword()
+	if err != nil {
+		utility.ResponseError(c, "密码生成错误: "+err.Error())
+		return
+	}
+	if err := adminService.SetPassword(target.ID, pwd, true); err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+	if err := adminService.RevokeAllSessions(target.ID); err != nil {
+		log.Println(err)
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"admin_id": target.ID,
+		"account":  target.Account,
+		"password": pwd,
+	})
+}
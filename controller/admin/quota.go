@@ -0,0 +1,138 @@
+package admin
+
+import (
+	"errors"
+	"log"
+	"time"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/service/adminService"
+	"walk-server/service/quotaService"
+	"walk-server/service/routeService"
+	"walk-server/service/teamService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetQuotas 获取每天各路线的总名额、剩余名额和已用名额
+func GetQuotas(c *gin.Context) {
+	quotas, err := quotaService.GetQuotas()
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"days":   quotaService.Days(),
+		"quotas": quotas,
+	})
+}
+
+type SetQuotaForm struct {
+	Day   uint8  `json:"day"`
+	Route uint8  `json:"route" binding:"required"`
+	Total *int64 `json:"total" binding:"required,min=0"`
+}
+
+// SetQuota 设置某天某路线的总名额
+func SetQuota(c *gin.Context) {
+	var postForm SetQuotaForm
+	if err := c.ShouldBindJSON(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+	if !checkQuotaTarget(c, postForm.Day, postForm.Route) {
+		return
+	}
+
+	change, err := quotaService.SetTotal(postForm.Day, postForm.Route, *postForm.Total)
+	respondQuotaChange(c, postForm.Day, postForm.Route, change, err)
+}
+
+type AddQuotaForm struct {
+	Day   uint8 `json:"day"`
+	Route uint8 `json:"route" binding:"required"`
+	Num   int64 `json:"num" binding:"required"` // 为负数时减少名额
+}
+
+// AddQuota 为某天某路线追加名额
+func AddQuota(c *gin.Context) {
+	var postForm AddQuotaForm
+	if err := c.ShouldBindJSON(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+	if !checkQuotaTarget(c, postForm.Day, postForm.Route) {
+		return
+	}
+
+	change, err := quotaService.Add(postForm.Day, postForm.Route, postForm.Num)
+	respondQuotaChange(c, postForm.Day, postForm.Route, change, err)
+}
+
+// GetQuotaLogs 获取名额调整记录
+func GetQuotaLogs(c *gin.Context) {
+	logs, err := model.GetQuotaLogs()
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"logs": logs,
+	})
+}
+
+func checkQuotaTarget(c *gin.Context, day uint8, route uint8) bool {
+	if day >= quotaService.Days() {
+		utility.ResponseError(c, "报名日期错误")
+		return false
+	}
+	if !routeService.Exists(route) {
+		utility.ResponseError(c, "路线不存在")
+		return false
+	}
+	return true
+}
+
+// respondQuotaChange 记录名额调整并返回调整结果
+func respondQuotaChange(c *gin.Context, day uint8, route uint8, change *quotaService.Change, err error) {
+	if errors.Is(err, quotaService.ErrQuotaNotEnough) {
+		utility.ResponseError(c, "已使用的名额超过调整后的数量")
+		return
+	} else if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	user, _ := adminService.GetAdminByJWT(c)
+	err = model.InsertQuotaLog(&model.QuotaLog{
+		AdminID:       user.ID,
+		Day:           day,
+		Route:         route,
+		PrevTotal:     change.PrevTotal,
+		Total:         change.Total,
+		PrevRemaining: change.PrevRemaining,
+		Remaining:     change.Remaining,
+		Time:          time.Now(),
+	})
+	if err != nil {
+		log.Println(err)
+	}
+
+	// 名额增加后为候补队伍自动提交
+	if change.Remaining > change.PrevRemaining {
+		teamService.PromoteWaitlist(quotaService.Key(day, route))
+	}
+
+	quota, _ := global.Rdb.Get(global.Rctx, quotaService.Key(day, route)).Int64()
+	utility.ResponseSuccess(c, gin.H{
+		"total":     change.Total,
+		"remaining": quota,
+		"used":      change.Total - quota,
+	})
+}
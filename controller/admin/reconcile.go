@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"errors"
+	"log"
+	"walk-server/service/teamService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetReconcile 对比 Redis 和 MySQL 的提交状态及名额，不做修改
+func GetReconcile(c *gin.Context) {
+	report, err := teamService.Diff()
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"report": report,
+	})
+}
+
+type ReconcileForm struct {
+	Direction string `json:"direction" binding:"required,oneof=mysql redis"` // mysql 以 Redis 为准修正 MySQL，redis 以 MySQL 为准修正 Redis
+}
+
+// Reconcile 按指定方向修正提交状态和名额，返回修正前的差异
+func Reconcile(c *gin.Context) {
+	var postForm ReconcileForm
+	if err := c.ShouldBindJSON(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+
+	report, err := teamService.Reconcile(postForm.Direction)
+	if errors.Is(err, teamService.ErrInvalidDirection) {
+		utility.ResponseError(c, "参数错误")
+		return
+	} else if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	utility.ResponseSuccess(c, gin.H{
+		"report": report,
+	})
+}
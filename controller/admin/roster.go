@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"log"
+	"net/url"
+	"time"
+	"walk-server/model"
+	"walk-server/service/adminService"
+	"walk-server/service/quotaService"
+	"walk-server/service/routeService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportRosterForm struct {
+	Route  uint8  `form:"route"`                          // 路线，0 为有权限的全部路线
+	Day    *uint8 `form:"day"`                            // 出发日期（从 0 开始）
+	Campus uint8  `form:"campus" binding:"oneof=0 1 2 3"` // 校区
+	Type   uint8  `form:"type" binding:"oneof=0 1 2 3"`   // 参与者类型
+}
+
+var (
+	rosterGenders  = map[int8]string{1: "男", 2: "女"}
+	rosterCampuses = map[uint8]string{1: "朝晖", 2: "屏峰", 3: "莫干山"}
+	rosterTypes    = map[uint8]string{1: "学生", 2: "教职工", 3: "校友"}
+	rosterRoles    = map[uint8]string{1: "队员", 2: "队长"}
+)
+
+// ExportRoster 导出已提交队伍的完整名单（用于保险和车辆安排），每条路线一个工作表
+// 成员分批读取并逐批写入工作表，文件直接以下载的形式返回
+func ExportRoster(c *gin.Context) {
+	var postForm ExportRosterForm
+	if err := c.ShouldBindQuery(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+
+	// 确定导出的路线，路线管理员只能导出自己片区的路线
+	user, _ := adminService.GetAdminByJWT(c)
+	var routes []model.Route
+	if postForm.Route != 0 {
+		route, ok := routeService.GetRoute(postForm.Route)
+		if !ok {
+			utility.ResponseError(c, "路线不存在")
+			return
+		}
+		if !checkManageRoute(c, route.ID) {
+			return
+		}
+		routes = append(routes, *route)
+	} else {
+		for _, route := range routeService.GetRoutes() {
+			if adminService.CanManageRoute(user, route.ID) {
+				routes = append(routes, route)
+			}
+		}
+	}
+
+	submitted, err := adminService.GetSubmittedTeams()
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	stream, err := utility.NewExcelStream()
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+	defer stream.Close()
+
+	filter := adminService.RosterFilter{
+		Day:    postForm.Day,
+		Campus: postForm.Campus,
+		Type:   postForm.Type,
+	}
+	headers := []string{"队伍编号", "队伍名称", "出发日期", "队伍担当", "姓名", "性别", "身份证号", "学号", "电话", "校区", "学院", "参与者类型"}
+	for _, route := range routes {
+		// 没有成员的路线不生成工作表，读到第一批成员时再创建
+		sheetAdded := false
+		err := adminService.EachRosterBatch(route.ID, filter, submitted, func(members []adminService.RosterMember) error {
+			if !sheetAdded {
+				if err := stream.AddSheet(rosterSheetName(route.Name), headers); err != nil {
+					return err
+				}
+				sheetAdded = true
+			}
+			rows := make([][]any, 0, len(members))
+			for _, member := range members {
+				date := ""
+				if member.Day != nil {
+					date = quotaService.StartDay().AddDate(0, 0, int(*member.Day)).Format(time.DateOnly)
+				}
+				rows = append(rows, []any{
+					member.TeamId,
+					member.TeamName,
+					date,
+					rosterRoles[member.Status],
+					member.Name,
+					rosterGenders[member.Gender],
+					member.Identity,
+					member.StuId,
+					member.Tel,
+					rosterCampuses[member.Campus],
+					member.College,
+					rosterTypes[member.Type],
+				})
+			}
+			return stream.WriteRows(rows)
+		})
+		if err != nil {
+			log.Println(err)
+			utility.ResponseError(c, "服务错误")
+			return
+		}
+	}
+	if stream.Sheets() == 0 {
+		utility.ResponseError(c, "没有符合条件的队伍")
+		return
+	}
+
+	fileName := "报名名单" + time.Now().Format("20060102150405") + ".xlsx"
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(fileName))
+	if err := stream.Output(c.Writer); err != nil {
+		log.Println(err)
+		// 响应已经开始写出时只能记录错误
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			utility.ResponseError(c, "生成文件失败")
+		}
+	}
+}
+
+// rosterSheetName 工作表名称不能超过 31 个字节，超出时按字符截断
+func rosterSheetName(name string) string {
+	for len(name) > utility.MaxSheetNameLen {
+		runes := []rune(name)
+		name = string(runes[:len(runes)-1])
+	}
+	return name
+}
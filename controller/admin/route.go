@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"log"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/service/routeService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRoutes 获取路线目录
+func GetRoutes(c *gin.Context) {
+	utility.ResponseSuccess(c, gin.H{
+		"routes": routeService.GetRoutes(),
+	})
+}
+
+type SaveRouteForm struct {
+	ID        uint8    `json:"id" binding:"required,max=9"` // 名额的键为 day*10+route，路线编号需小于 10
+	Code      string   `json:"code" binding:"required"`
+	Name      string   `json:"name" binding:"required"`
+	Area      uint8    `json:"area"`
+	Points    []string `json:"points" binding:"required,min=2,max=100,dive,required"` // 按顺序排列，第一个为起点，最后一个为终点
+	Durations []uint16 `json:"durations"`                                             // 可选，与 points 一一对应，为从上一点位走到该点位的预计分钟数，用于失联检测
+}
+
+// SaveRoute 新建或修改路线及其点位
+func SaveRoute(c *gin.Context) {
+	var postForm SaveRouteForm
+	if err := c.ShouldBindJSON(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+
+	if len(postForm.Durations) > 0 && len(postForm.Durations) != len(postForm.Points) {
+		utility.ResponseError(c, "预计用时数量与点位数量不一致")
+		return
+	}
+
+	for _, r := range routeService.GetRoutes() {
+		if r.Code == postForm.Code && r.ID != postForm.ID {
+			utility.ResponseError(c, "路线标识已被使用")
+			return
+		}
+	}
+
+	route := model.Route{
+		ID:   postForm.ID,
+		Code: postForm.Code,
+		Name: postForm.Name,
+		Area: postForm.Area,
+	}
+	for i, name := range postForm.Points {
+		point := model.RoutePoint{Name: name}
+		if len(postForm.Durations) > 0 {
+			point.Duration = postForm.Durations[i]
+		}
+		route.Points = append(route.Points, point)
+	}
+
+	if err := routeService.Save(&route); err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+	utility.ResponseSuccess(c, nil)
+}
+
+type DeleteRouteForm struct {
+	ID uint8 `json:"id" binding:"required"`
+}
+
+// DeleteRoute 删除路线，已有队伍的路线无法删除
+func DeleteRoute(c *gin.Context) {
+	var postForm DeleteRouteForm
+	if err := c.ShouldBindJSON(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+
+	if !routeService.Exists(postForm.ID) {
+		utility.ResponseError(c, "路线不存在")
+		return
+	}
+
+	var count int64
+	global.DB.Model(&model.Team{}).Where("route = ?", postForm.ID).Count(&count)
+	if count > 0 {
+		utility.ResponseError(c, "该路线已有队伍，无法删除")
+		return
+	}
+
+	if err := routeService.Delete(postForm.ID); err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+	utility.ResponseSuccess(c, nil)
+}
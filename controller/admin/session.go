@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"log"
+	"walk-server/model"
+	"walk-server/service/adminService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getSessionTarget 获取要管理登录会话的管理员，管理自己的会话不需要权限，
+// 超级管理员可以管理所有人，路线负责人可以管理所在片区的点位工作人员
+func getSessionTarget(c *gin.Context, adminID uint) (*model.Admin, bool) {
+	user, _ := adminService.GetAdminByJWT(c)
+	if adminID == 0 || adminID == user.ID {
+		return user, true
+	}
+
+	target, err := adminService.GetAdminByID(adminID)
+	if err != nil {
+		utility.ResponseError(c, "管理员不存在")
+		return nil, false
+	}
+	if user.MustChangePassword || !adminService.HasPermission(user.Role, adminService.PermSessionManage) ||
+		(user.Role != model.RoleSuper && (target.Role != model.RoleStaff || !adminService.CanManageRoute(user, target.Route))) {
+		utility.ResponseError(c, "没有权限")
+		return nil, false
+	}
+	return target, true
+}
+
+type GetSessionsForm struct {
+	AdminID uint `form:"admin_id"` // 为空时获取自己的会话
+}
+
+// GetSessions 获取管理员的登录设备
+func GetSessions(c *gin.Context) {
+	var postForm GetSessionsForm
+	if err := c.ShouldBindQuery(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+	target, ok := getSessionTarget(c, postForm.AdminID)
+	if !ok {
+		return
+	}
+
+	sessions, err := adminService.GetSessions(target.ID)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
+
+	claims, _ := c.Get("claims")
+	utility.ResponseSuccess(c, gin.H{
+		"sessions": sessions,
+		"current":  claims.(*utility.AdminClaims).SessionID,
+	})
+}
+
+type RevokeSessionForm struct {
+	AdminID uint   `json:"admin_id"` // 为空时吊销自己的会话
+	SID     string `json:"sid"`      // 为空时吊销该管理员的所有会话
+}
+
+// RevokeSession 将管理员的登录设备踢下线
+func RevokeSession(c *gin.Context) {
+	var postForm RevokeSessionForm
+	if err := c.ShouldBindJSON(&postForm); err != nil {
+		utility.ResponseError(c, "参数错误")
+		return
+	}
+	target, ok := getSessionTarget(c, postForm.AdminID)
+	if !ok {
+		return
+	}
+
+	if postForm.SID == "" {
+		if err := adminService.RevokeAllSessions(target.ID); err != nil {
+			log.Println(err)
+			utility.ResponseError(c, "服务错误")
+			return
+		}
+		utility.ResponseSuccess(c, nil)
+		return
+	}
+
+	found, err := adminService.RevokeSession(target.ID, postForm.SID)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	} else if !found {
+		utility.ResponseError(c, "登录设备不存在")
+		return
+	}
+	utility.ResponseSuccess(c, nil)
+}
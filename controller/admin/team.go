@@ -2,14 +2,17 @@ package admin
 
 import (
 	"errors"
+	"io"
 	"log"
 	"strconv"
 	"time"
-	"walk-server/constant"
 	"walk-server/global"
 	"walk-server/middleware"
 	"walk-server/model"
 	"walk-server/service/adminService"
+	"walk-server/service/policyService"
+	"walk-server/service/posterService"
+	"walk-server/service/routeService"
 	"walk-server/service/teamService"
 	"walk-server/service/userService"
 	"walk-server/utility"
@@ -47,6 +50,7 @@ func GetTeam(c *gin.Context) {
 		return
 	}
 
+	middleware.SetAuditTeam(c, team.ID)
 	b := middleware.CheckRoute(user, team)
 	if !b {
 		utility.ResponseError(c, "该队伍为其他路线")
@@ -72,7 +76,7 @@ func GetTeam(c *gin.Context) {
 			"walk_status": member.WalkStatus,
 		})
 	}
-	point := constant.GetPointName(team.Route, team.Point)
+	point := routeService.GetPointName(team.Route, team.Point)
 	utility.ResponseSuccess(c, gin.H{
 		"team": gin.H{
 			"id":          team.ID,
@@ -113,6 +117,7 @@ func BindTeam(c *gin.Context) {
 		return
 	}
 
+	middleware.SetAuditTeam(c, team.ID)
 	b := middleware.CheckRoute(user, team)
 	if !b {
 		utility.ResponseError(c, "该队伍为其他路线")
@@ -148,17 +153,19 @@ func BindTeam(c *gin.Context) {
 		return
 	}
 
-	if (team.Num+1)/2 > uint8(num) {
-		utility.ResponseError(c, "团队人数不足，无法绑定")
+	if num < policyService.GetPolicy(team.Route).StartNum(team) {
+		utility.ResponseError(c, "到场人数不足，无法绑定")
 		return
 	}
 
+	prev := *team
 	team.Code = postForm.Code
 	team.Point = 0
 	team.Status = 5
 	team.StartNum = num
 	team.Time = time.Now()
 	teamService.Update(team)
+	recordCheckpoint(user, prev, team, num, nil)
 	utility.ResponseSuccess(c, nil)
 }
 
@@ -197,6 +204,7 @@ func UpdateTeamStatus(c *gin.Context) {
 		return
 	}
 
+	middleware.SetAuditTeam(c, team.ID)
 	b := middleware.CheckRoute(user, team)
 	if !b {
 		utility.ResponseError(c, "该队伍为其他路线")
@@ -218,44 +226,37 @@ func UpdateTeamStatus(c *gin.Context) {
 		}
 	}
 
+	prev := *team
 	if num == 0 {
 		team.Status = 3
-		team.Point = int8(constant.PointMap[team.Route])
+		team.Point = routeService.GetEndPoint(team.Route)
+		team.Time = time.Now()
 		teamService.Update(team)
+		recordCheckpoint(user, prev, team, 0, nil)
 		utility.ResponseSuccess(c, gin.H{
 			"progress_num": 0,
 		})
 		return
 	}
 
-	// 各路线点位签到逻辑设置
-	switch team.Route {
-	case 2:
-		if user.Route == 3 && (user.Point == 2 || user.Point == 3 || user.Point == 4) {
-			global.Rdb.SAdd(global.Rctx, "wrong_route_teams:pfAll", team.ID)
-			utility.ResponseError(c, "该队伍为半程路线，让队伍继续往前走就行")
-			return
-		}
-		if user.Route == 3 && user.Point > 2 {
-			team.Point = user.Point - 2
-		} else {
-			team.Point = user.Point
-		}
-		global.Rdb.SRem(global.Rctx, "wrong_route_teams:pfAll", team.ID)
-	case 3:
-		if user.Route == 2 && user.Point == 2 {
-			global.Rdb.SAdd(global.Rctx, "wrong_route_teams:pfHalf", team.ID)
-			utility.ResponseError(c, "该队伍为全程路线，让队伍继续往前走就行")
-			return
+	// 同一片区的路线按点位名称共用点位，队伍的路线上没有该点位时说明走错了路线
+	point, ok := routeService.MapPoint(user.Route, user.Point, team.Route)
+	if !ok {
+		global.Rdb.SAdd(global.Rctx, wrongRouteKey(user.Route), team.ID)
+		utility.ResponseError(c, "该队伍为"+routeService.GetRouteName(team.Route)+"路线，让队伍继续往前走就行")
+		return
+	}
+	team.Point = point
+	for _, route := range routeService.GetRoutes() {
+		if route.ID != team.Route && routeService.IsSameArea(route.ID, team.Route) {
+			global.Rdb.SRem(global.Rctx, wrongRouteKey(route.ID), team.ID)
 		}
-		team.Point = user.Point
-		global.Rdb.SRem(global.Rctx, "wrong_route_teams:pfHalf", team.ID)
-	default:
-		team.Point = user.Point
 	}
 
+	var members []model.MemberWalkStatus
 	for _, p := range persons {
 		if p.WalkStatus == 3 {
+			members = append(members, model.MemberWalkStatus{OpenID: p.OpenId, PrevStatus: p.WalkStatus, Status: 2})
 			p.WalkStatus = 2
 			userService.Update(&p)
 		}
@@ -263,7 +264,9 @@ func UpdateTeamStatus(c *gin.Context) {
 	team.Time = time.Now()
 	team.Status = 2
 	team.IsLost = false
+	team.LostReason = ""
 	teamService.Update(team)
+	recordCheckpoint(user, prev, team, num, members)
 	utility.ResponseSuccess(c, gin.H{
 		"progress_num": num,
 	})
@@ -290,6 +293,7 @@ func PostDestination(c *gin.Context) {
 		return
 	}
 
+	middleware.SetAuditTeam(c, team.ID)
 	b := middleware.CheckRoute(user, team)
 	if !b {
 		utility.ResponseError(c, "该队伍为其他路线")
@@ -310,39 +314,53 @@ func PostDestination(c *gin.Context) {
 		return
 	}
 
-	team.Point = int8(constant.PointMap[team.Route])
+	prev := *team
+	team.Point = routeService.GetEndPoint(team.Route)
+	team.Time = time.Now()
 
 	if num == 0 {
 		team.Status = 3
 		teamService.Update(team)
+		recordCheckpoint(user, prev, team, 0, nil)
 		utility.ResponseSuccess(c, nil)
 		return
 	}
 
-	team.Time = time.Now()
-
 	if postForm.Status == 1 {
+		var members []model.MemberWalkStatus
 		for _, p := range persons {
 			if p.WalkStatus == 2 || p.WalkStatus == 3 {
+				members = append(members, model.MemberWalkStatus{OpenID: p.OpenId, PrevStatus: p.WalkStatus, Status: 5})
 				p.WalkStatus = 5
 				userService.Update(&p)
 			}
 		}
 		team.Status = 4
 		teamService.Update(team)
+		recordCheckpoint(user, prev, team, num, members)
 		utility.ResponseSuccess(c, nil)
 		return
 	} else {
 		team.Status = 3
 		teamService.Update(team)
+		recordCheckpoint(user, prev, team, num, nil)
 		utility.ResponseSuccess(c, nil)
 		return
 	}
 }
 
+// checkManageRoute 检查当前管理员能否管理该路线的队伍
+func checkManageRoute(c *gin.Context, route uint8) bool {
+	user, _ := adminService.GetAdminByJWT(c)
+	if !adminService.CanManageRoute(user, route) {
+		utility.ResponseError(c, "没有管理该路线的权限")
+		return false
+	}
+	return true
+}
+
 type RegroupForm struct {
 	Jwts   []string `json:"jwts" binding:"required"`
-	Secret string   `json:"secret" binding:"required"`
 	Route  uint8    `json:"route" binding:"required"`
 	Name   string   `json:"name"`
 	Slogan string   `json:"slogan"`
@@ -356,8 +374,23 @@ func Regroup(c *gin.Context) {
 		utility.ResponseError(c, "参数错误")
 		return
 	}
-	if postForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
+	if !routeService.Exists(postForm.Route) {
+		utility.ResponseError(c, "路线不存在")
+		return
+	}
+	// 非超级管理员只能为自己片区的路线重新分组
+	if !checkManageRoute(c, postForm.Route) {
+		return
+	}
+
+	// 在修改原队伍之前检查人数上限
+	uniqueJwts := make(map[string]bool)
+	for _, jwt := range postForm.Jwts {
+		uniqueJwts[jwt] = true
+	}
+	policy := policyService.GetPolicy(postForm.Route)
+	if len(uniqueJwts) == 0 || len(uniqueJwts) > int(policy.MaxMembers) {
+		utility.ResponseError(c, "队伍人数应为1到"+strconv.Itoa(int(policy.MaxMembers))+"人")
 		return
 	}
 
@@ -410,6 +443,7 @@ func Regroup(c *gin.Context) {
 			userService.Update(&captain)
 
 			// 删除队伍
+			posterService.Invalidate(team.ID)
 			if err := teamService.Delete(team); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 				log.Println(err)
 				utility.ResponseError(c, "服务错误")
@@ -448,6 +482,7 @@ func Regroup(c *gin.Context) {
 		utility.ResponseError(c, "服务错误")
 		return
 	}
+	middleware.SetAuditTeam(c, newTeam.ID)
 
 	// 更新每个人的队伍ID
 	for i, person := range persons {
@@ -460,6 +495,7 @@ func Regroup(c *gin.Context) {
 		userService.Update(person)
 	}
 	global.Rdb.SAdd(global.Rctx, "teams", strconv.Itoa(int(newTeam.ID)))
+	adminService.NotifyDetailChanged(newTeam.Route)
 
 	utility.ResponseSuccess(c, gin.H{
 		"team_id": newTeam.ID,
@@ -467,8 +503,7 @@ func Regroup(c *gin.Context) {
 }
 
 type SubmitTeamForm struct {
-	TeamID uint   `json:"team_id" binding:"required"`
-	Secret string `json:"secret" binding:"required"`
+	TeamID uint `json:"team_id" binding:"required"`
 }
 
 func SubmitTeam(c *gin.Context) {
@@ -479,198 +514,61 @@ func SubmitTeam(c *gin.Context) {
 		utility.ResponseError(c, "参数错误")
 		return
 	}
-	if postForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
-		return
-	}
 	team, err := teamService.GetTeamByID(postForm.TeamID)
 	if team == nil || err != nil {
 		utility.ResponseError(c, "队伍查找失败，请重新核对")
 		return
 	}
+	if !checkManageRoute(c, team.Route) {
+		return
+	}
 
 	team.Submit = true
 	teamService.Update(team)
 	global.Rdb.SAdd(global.Rctx, "teams", strconv.Itoa(int(team.ID)))
+	adminService.NotifyDetailChanged(team.Route)
 	utility.ResponseSuccess(c, nil)
 
 }
 
-type GetDetailForm struct {
-	Secret string `form:"secret" binding:"required"`
-}
-
-type RouteDetail struct {
-	Count2 *int64 `json:"count2,omitempty"`
-	Count  int64  `json:"count"`
-	Label  string `json:"label"`
-}
-
 // GetDetail 获取全部路线的点位信息
 func GetDetail(c *gin.Context) {
-	var postForm GetDetailForm
-	if err := c.ShouldBindQuery(&postForm); err != nil {
-		utility.ResponseError(c, "参数错误")
-		return
-	}
-	if postForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
-		return
+	data := gin.H{}
+	for key, details := range adminService.GetAllRouteDetail() {
+		data[key] = details
 	}
 
-	routes := map[string]int{
-		"zh":      1,
-		"pfHalf":  2,
-		"pfAll":   3,
-		"mgsHalf": 4,
-		"mgsAll":  5,
-	}
-
-	resultMap := make(map[string][]int64)
-	for key, route := range routes {
-		resultMap[key] = make([]int64, constant.PointMap[uint8(route)]+3)
-	}
-
-	// 获取各点位人数（当前在该点位的人数）
-	getPointCounts := func(route int, status []int, teamStatus []int, points []int64) {
-		var pointCounts []struct {
-			Point int64
-			Count int64
-		}
-		global.DB.Model(&model.Person{}).
-			Select("teams.point, count(*) as count").
-			Joins("JOIN teams ON people.team_id = teams.id").
-			Where("teams.route = ? AND people.walk_status IN ? AND teams.status IN ?", route, status, teamStatus).
-			Group("teams.point").
-			Order("teams.point").
-			Scan(&pointCounts)
-
-		for _, pointCount := range pointCounts {
-			if pointCount.Point >= 0 && int(pointCount.Point) < int(constant.PointMap[uint8(route)])+1 {
-				points[pointCount.Point+1] = pointCount.Count
-			}
-		}
-
-	}
-
-	// 获取各路线未开始人数
-	getStartCounts := func(route int, points *int64) {
-		global.DB.Model(&model.Person{}).
-			Select("count(*) as count").
-			Joins("JOIN teams ON people.team_id = teams.id").
-			Where("teams.route = ? AND people.walk_status = 1 And teams.submit = 1", route).
-			Pluck("count", points)
-	}
-
-	// 获取各路线已结束和下撤人数
-	appendEndCounts := func(route int, points []int64) {
-		var endCount5, endCount4 int64
-		global.DB.Model(&model.Person{}).
-			Select("count(*) as count").
-			Joins("JOIN teams ON people.team_id = teams.id").
-			Where("teams.route = ? AND people.walk_status = 5", route).
-			Pluck("count", &endCount5)
-		global.DB.Model(&model.Person{}).
-			Select("count(*) as count").
-			Joins("JOIN teams ON people.team_id = teams.id").
-			Where("teams.route = ? AND people.walk_status = 4", route).
-			Pluck("count", &endCount4)
-		points[len(points)-2] = endCount5
-		points[len(points)-1] = endCount4
-	}
-
-	// 状态：进行中、未出发、已结束
-	personStatusInProgress := []int{2, 3}
-	teamStatusInProgress := []int{2, 5}
-
-	for key, route := range routes {
-		getPointCounts(route, personStatusInProgress, teamStatusInProgress, resultMap[key])
-		getStartCounts(route, &resultMap[key][0])
-		appendEndCounts(route, resultMap[key])
-	}
-
-	processRoute := func(routeName string, routeID int) []RouteDetail {
-		details := make([]RouteDetail, len(resultMap[routeName]))
-
-		// 先计算每个点位的count2（经过该点位的总人数）
-		count2Array := make([]int64, len(resultMap[routeName]))
-		for i := len(resultMap[routeName]) - 3; i >= 0; i-- { // 从倒数第三个开始向前累加
-			if i == len(resultMap[routeName])-3 {
-				count2Array[i] = resultMap[routeName][i]
-			} else {
-				count2Array[i] = resultMap[routeName][i] + count2Array[i+1]
-			}
-		}
-
-		for i, count := range resultMap[routeName] {
-			label := ""
-			switch i {
-			case 0:
-				label = "未出发"
-			case len(resultMap[routeName]) - 2:
-				label = "已结束"
-			case len(resultMap[routeName]) - 1:
-				label = "下撤"
-			default:
-				label = constant.GetPointName(uint8(routeID), int8(i-1))
-			}
-
-			// 只有普通点位需要count2
-			if i != 0 && i != len(resultMap[routeName])-2 && i != len(resultMap[routeName])-1 {
-				// 使用累加计算出的经过该点位的总人数
-				details[i] = RouteDetail{
-					Count:  count,
-					Count2: &count2Array[i],
-					Label:  label,
-				}
-			} else {
-				// 特殊标签不需要count2
-				details[i] = RouteDetail{
-					Count: count,
-					Label: label,
-				}
-			}
-		}
-		return details
-	}
+	// 返回结果
+	utility.ResponseSuccess(c, data)
+}
 
-	// 处理各路线的数据
-	zhDetails := processRoute("zh", 1)
-	pfAllDetails := processRoute("pfAll", 3)
-	pfHalfDetails := processRoute("pfHalf", 2)
-	mgsHalfDetails := processRoute("mgsHalf", 4)
-	mgsAllDetails := processRoute("mgsAll", 5)
-
-	// 为每条路线添加已出发总人数
-	addStartedTotal := func(details []RouteDetail) []RouteDetail {
-		var startedTotal int64
-		// 计算除了"未出发"之外的所有人员数量
-		for i, detail := range details {
-			// 跳过"未出发"（索引0）
-			if i > 0 {
-				startedTotal += detail.Count
+// GetDetailStream 通过 Server-Sent Events 推送各路线点位人数
+// 连接建立后先推送一次全量数据（detail 事件），之后每当扫码改变人数时推送发生变化的路线（update 事件）
+func GetDetailStream(c *gin.Context) {
+	updates, cancel := adminService.SubscribeDetail()
+	defer cancel()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // 关闭 nginx 缓冲
+	c.SSEvent("detail", adminService.GetAllRouteDetail())
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return false
 			}
+			c.SSEvent("update", update)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		case <-c.Request.Context().Done():
+			return false
 		}
-		// 添加已出发总人数标签
-		return append([]RouteDetail{{
-			Count: startedTotal,
-			Label: "已出发",
-		}}, details...)
-	}
-
-	zhDetails = addStartedTotal(zhDetails)
-	pfAllDetails = addStartedTotal(pfAllDetails)
-	pfHalfDetails = addStartedTotal(pfHalfDetails)
-	mgsHalfDetails = addStartedTotal(mgsHalfDetails)
-	mgsAllDetails = addStartedTotal(mgsAllDetails)
-
-	// 返回结果
-	utility.ResponseSuccess(c, gin.H{
-		"zh":      zhDetails,
-		"pfAll":   pfAllDetails,
-		"pfHalf":  pfHalfDetails,
-		"mgsHalf": mgsHalfDetails,
-		"mgsAll":  mgsAllDetails,
 	})
 }
 
@@ -681,41 +579,13 @@ type Result struct {
 	TotalNum int64
 }
 
-// 定义结果类型
-type Results struct {
-	Zh      []Result `json:"zh"`
-	PfHalf  []Result `json:"pfHalf"`
-	PfAll   []Result `json:"pfAll"`
-	MgsHalf []Result `json:"mgsHalf"`
-	MgsAll  []Result `json:"mgsAll"`
-}
+// Results 各路线的统计结果，键为路线标识
+type Results map[string][]Result
 
 // GetSubmitDetail 获取已提交队伍信息
 func GetSubmitDetail(c *gin.Context) {
-	var postForm GetDetailForm
-	if err := c.ShouldBindQuery(&postForm); err != nil {
-		utility.ResponseError(c, "参数错误")
-		return
-	}
-	if postForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
-		return
-	}
-
 	// 创建结果集合
-	var results Results
-
-	// 定义路线和队伍类型的映射
-	routes := []struct {
-		Name  string
-		Route int
-	}{
-		{"朝晖", 1},
-		{"屏峰半程", 2},
-		{"屏峰全程", 3},
-		{"莫干山半程", 4},
-		{"莫干山全程", 5},
-	}
+	results := make(Results)
 
 	teamTypes := []struct {
 		Type    int
@@ -729,47 +599,15 @@ func GetSubmitDetail(c *gin.Context) {
 	}
 
 	// 获取各个路线的队伍数据
-	for _, r := range routes {
+	for _, r := range routeService.GetRoutes() {
 		for _, t := range teamTypes {
-			teamCount, totalCount := getTeamStats(r.Route, t.Type, t.IsMixed)
-
-			switch r.Name {
-			case "朝晖":
-				results.Zh = append(results.Zh, Result{
-					Route:    r.Name,
-					TeamType: t.Name,
-					TeamNum:  teamCount,
-					TotalNum: totalCount,
-				})
-			case "屏峰半程":
-				results.PfHalf = append(results.PfHalf, Result{
-					Route:    r.Name,
-					TeamType: t.Name,
-					TeamNum:  teamCount,
-					TotalNum: totalCount,
-				})
-			case "屏峰全程":
-				results.PfAll = append(results.PfAll, Result{
-					Route:    r.Name,
-					TeamType: t.Name,
-					TeamNum:  teamCount,
-					TotalNum: totalCount,
-				})
-			case "莫干山半程":
-				results.MgsHalf = append(results.MgsHalf, Result{
-					Route:    r.Name,
-					TeamType: t.Name,
-					TeamNum:  teamCount,
-					TotalNum: totalCount,
-				})
-			case "莫干山全程":
-				results.MgsAll = append(results.MgsAll, Result{
-					Route:    r.Name,
-					TeamType: t.Name,
-					TeamNum:  teamCount,
-					TotalNum: totalCount,
-				})
-			}
+			teamCount, totalCount := getTeamStats(int(r.ID), t.Type, t.IsMixed)
+			results[r.Code] = append(results[r.Code], Result{
+				Route:    r.Name,
+				TeamType: t.Name,
+				TeamNum:  teamCount,
+				TotalNum: totalCount,
+			})
 		}
 	}
 
@@ -805,27 +643,26 @@ func getTeamStats(route int, captainType int, hasStudent bool) (int64, int64) {
 }
 
 type allTeamForm struct {
-	Secret string `form:"secret" binding:"required"`
-	TeamID uint   `form:"team_id" binding:"required"` // 团队码为team_id
+	TeamID uint `form:"team_id" binding:"required"` // 团队码为team_id
 }
 
-func GetTeamBySecret(c *gin.Context) {
+// GetTeamInfo 通过队伍编号获取队伍详情
+func GetTeamInfo(c *gin.Context) {
 	var postForm allTeamForm
 	err := c.ShouldBindQuery(&postForm)
 	if err != nil {
 		utility.ResponseError(c, "参数错误")
 		return
 	}
-	if postForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
-		return
-	}
 	var team *model.Team
 	team, err = teamService.GetTeamByID(postForm.TeamID)
 	if team == nil || err != nil {
 		utility.ResponseError(c, "队伍编号输入错误，队伍查找失败")
 		return
 	}
+	if !checkManageRoute(c, team.Route) {
+		return
+	}
 
 	var persons []model.Person
 	global.DB.Where("team_id = ?", team.ID).Find(&persons)
@@ -847,7 +684,7 @@ func GetTeamBySecret(c *gin.Context) {
 			"walk_status": member.WalkStatus,
 		})
 	}
-	point := constant.GetPointName(team.Route, team.Point)
+	point := routeService.GetPointName(team.Route, team.Point)
 	utility.ResponseSuccess(c, gin.H{
 		"team": gin.H{
 			"id":          team.ID,
@@ -868,8 +705,7 @@ func GetTeamBySecret(c *gin.Context) {
 
 // SetTeamLostForm 标记队伍失联表单
 type SetTeamLostForm struct {
-	TeamID uint   `json:"team_id" binding:"required"`
-	Secret string `json:"secret" binding:"required"`
+	TeamID uint `json:"team_id" binding:"required"`
 }
 
 // SetTeamLost 标记队伍为失联
@@ -881,19 +717,20 @@ func SetTeamLost(c *gin.Context) {
 		return
 	}
 
-	if postForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
-		return
-	}
-
 	team, err := teamService.GetTeamByID(postForm.TeamID)
 	if team == nil || err != nil {
 		utility.ResponseError(c, "队伍查找失败，请重新核对")
 		return
 	}
+	if !checkManageRoute(c, team.Route) {
+		return
+	}
 
-	team.IsLost = !team.IsLost
-	teamService.Update(team)
+	if err := teamService.SetLost(team, !team.IsLost, "管理员标记"); err != nil {
+		log.Println(err)
+		utility.ResponseError(c, "服务错误")
+		return
+	}
 	if team.IsLost {
 		utility.ResponseData(c, 200, "标记失联成功", nil)
 	} else {
@@ -901,26 +738,8 @@ func SetTeamLost(c *gin.Context) {
 	}
 }
 
-// GetLostTeamsForm 获取失联队伍列表表单
-type GetLostTeamsForm struct {
-	Secret string `form:"secret" binding:"required"`
-}
-
 // GetLostTeams 获取失联队伍列表
 func GetLostTeams(c *gin.Context) {
-	var queryForm GetLostTeamsForm
-	err := c.ShouldBindQuery(&queryForm)
-
-	if err != nil {
-		utility.ResponseError(c, "参数错误")
-		return
-	}
-
-	if queryForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
-		return
-	}
-
 	var lostTeams []model.Team
 	global.DB.Where("is_lost = ?", true).Find(&lostTeams)
 
@@ -944,10 +763,13 @@ func GetLostTeams(c *gin.Context) {
 		}
 
 		teamData = append(teamData, gin.H{
-			"id":      team.ID,
-			"name":    team.Name,
-			"route":   team.Route,
-			"members": memberData,
+			"id":          team.ID,
+			"name":        team.Name,
+			"route":       team.Route,
+			"point":       routeService.GetPointName(team.Route, team.Point),
+			"time":        team.Time,
+			"lost_reason": team.LostReason,
+			"members":     memberData,
 		})
 	}
 
@@ -956,74 +778,55 @@ func GetLostTeams(c *gin.Context) {
 	})
 }
 
-// GetWrongRouteTeamsForm 获取走错路线队伍数量表单
-type GetWrongRouteTeamsForm struct {
-	Secret string `form:"secret" binding:"required"`
+// wrongRouteKey 走到 route 路线点位上的其他路线队伍
+func wrongRouteKey(route uint8) string {
+	code := strconv.Itoa(int(route))
+	if r, ok := routeService.GetRoute(route); ok {
+		code = r.Code
+	}
+	return "wrong_route_teams:" + code
+}
+
+type WrongRouteCount struct {
+	Route uint8  `json:"route"`
+	Code  string `json:"code"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"` // 走到该路线上的其他路线队伍的总人数
 }
 
 // GetWrongRouteTeams 获取走错路线的队伍数量
 func GetWrongRouteTeams(c *gin.Context) {
-	var queryForm GetWrongRouteTeamsForm
-	err := c.ShouldBindQuery(&queryForm)
-
-	if err != nil {
-		utility.ResponseError(c, "参数错误")
-		return
-	}
-
-	if queryForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
-		return
-	}
-
-	// 从Redis中获取走错路线的队伍ID列表
-	pfAllTeamIDs, err1 := global.Rdb.SMembers(global.Rctx, "wrong_route_teams:pfAll").Result()
-	pfHalfTeamIDs, err2 := global.Rdb.SMembers(global.Rctx, "wrong_route_teams:pfHalf").Result()
-	if err1 != nil || err2 != nil {
-		utility.ResponseError(c, "获取数据失败")
-		return
-	}
-
-	// 计算屏峰全程路线走错的队伍总人数
-	var pfAllCount int64
-	if len(pfAllTeamIDs) > 0 {
-		// 将字符串类型的ID转换为整数
-		var pfAllTeamIDInts []uint
-		for _, idStr := range pfAllTeamIDs {
-			if id, err := strconv.ParseUint(idStr, 10, 32); err == nil {
-				pfAllTeamIDInts = append(pfAllTeamIDInts, uint(id))
-			}
-		}
-
-		// 查询这些队伍的成员数量
-		if len(pfAllTeamIDInts) > 0 {
-			var count int64
-			global.DB.Model(&model.Person{}).Where("team_id IN ?", pfAllTeamIDInts).Count(&count)
-			pfAllCount = count
+	counts := make([]WrongRouteCount, 0)
+	data := gin.H{}
+	for _, route := range routeService.GetRoutes() {
+		// 不与其他路线共用点位的路线不会有走错的队伍
+		if route.Area == 0 {
+			continue
 		}
-	}
 
-	// 计算屏峰半程路线走错的队伍总人数
-	var pfHalfCount int64
-	if len(pfHalfTeamIDs) > 0 {
-		// 将字符串类型的ID转换为整数
-		var pfHalfTeamIDInts []uint
-		for _, idStr := range pfHalfTeamIDs {
-			if id, err := strconv.ParseUint(idStr, 10, 32); err == nil {
-				pfHalfTeamIDInts = append(pfHalfTeamIDInts, uint(id))
-			}
+		// 从Redis中获取走错路线的队伍ID列表
+		teamIDs, err := global.Rdb.SMembers(global.Rctx, wrongRouteKey(route.ID)).Result()
+		if err != nil {
+			utility.ResponseError(c, "获取数据失败")
+			return
 		}
 
 		// 查询这些队伍的成员数量
-		if len(pfHalfTeamIDInts) > 0 {
-			var count int64
-			global.DB.Model(&model.Person{}).Where("team_id IN ?", pfHalfTeamIDInts).Count(&count)
-			pfHalfCount = count
+		var count int64
+		if len(teamIDs) > 0 {
+			global.DB.Model(&model.Person{}).Where("team_id IN ?", teamIDs).Count(&count)
+		}
+		counts = append(counts, WrongRouteCount{Route: route.ID, Code: route.Code, Name: route.Name, Count: count})
+
+		// 兼容旧的看板，屏峰路线继续返回 pf_all_count 和 pf_half_count
+		switch route.Code {
+		case "pfAll":
+			data["pf_all_count"] = count // 屏峰走错为全程的数量
+		case "pfHalf":
+			data["pf_half_count"] = count // 屏峰走错为半程的数量
 		}
 	}
 
-	utility.ResponseSuccess(c, gin.H{
-		"pf_all_count":  pfAllCount,  // 屏峰走错为全程的数量
-		"pf_half_count": pfHalfCount, // 屏峰走错为半程的数量
-	})
+	data["routes"] = counts
+	utility.ResponseSuccess(c, data)
 }
@@ -7,15 +7,14 @@ import (
 	"math/rand"
 	"strconv"
 	"time"
-	"walk-server/constant"
 	"walk-server/global"
 	"walk-server/model"
+	"walk-server/service/routeService"
 	"walk-server/utility"
 )
 
 type CreateTestTeamData struct {
-	Secret string `json:"secret" binding:"required"`
-	Num    int    `json:"num" binding:"required"` // 队伍数量
+	Num int `json:"num" binding:"required"` // 队伍数量
 }
 
 func CreateTestTeams(c *gin.Context) {
@@ -25,16 +24,12 @@ func CreateTestTeams(c *gin.Context) {
 		return
 	}
 
-	if postForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
-		return
-	}
-
 	err := global.DB.Transaction(func(tx *gorm.DB) error {
 		// 1. 生成队伍数据（不插入数据库）
 		var teams []model.Team
+		routes := routeService.GetRoutes()
 		for i := 0; i < postForm.Num; i++ {
-			route := rand.Intn(5) + 1
+			route := routes[rand.Intn(len(routes))].ID
 			teams = append(teams, model.Team{
 				Name:       "测试队伍" + strconv.Itoa(i),
 				Num:        4,
@@ -42,7 +37,7 @@ func CreateTestTeams(c *gin.Context) {
 				Slogan:     "123",
 				AllowMatch: false,
 				Captain:    "", // 先留空，后面填充
-				Route:      uint8(route),
+				Route:      route,
 				Point:      0,
 				Status:     1,
 				StartNum:   0,
@@ -113,22 +108,7 @@ func CreateTestTeams(c *gin.Context) {
 	utility.ResponseSuccess(c, nil)
 }
 
-type DeleteTestTeamData struct {
-	Secret string `json:"secret" binding:"required"`
-}
-
 func DeleteTestTeams(c *gin.Context) {
-	var postForm DeleteTestTeamData
-	if err := c.ShouldBind(&postForm); err != nil {
-		utility.ResponseError(c, "参数错误")
-		return
-	}
-
-	if postForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
-		return
-	}
-
 	// 开启事务
 	err := global.DB.Transaction(func(tx *gorm.DB) error {
 		// 1. 删除 Team
@@ -152,22 +132,7 @@ func DeleteTestTeams(c *gin.Context) {
 	utility.ResponseSuccess(c, nil)
 }
 
-type UpdateTestTeamData struct {
-	Secret string `json:"secret" binding:"required"`
-}
-
 func UpdateTestTeams(c *gin.Context) {
-	var postForm UpdateTestTeamData
-	if err := c.ShouldBind(&postForm); err != nil {
-		utility.ResponseError(c, "参数错误")
-		return
-	}
-
-	if postForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
-		return
-	}
-
 	err := global.DB.Transaction(func(tx *gorm.DB) error {
 		// 1. 更新所有测试人员的 WalkStatus 为 2
 		if err := tx.Model(&model.Person{}).
@@ -187,7 +152,7 @@ func UpdateTestTeams(c *gin.Context) {
 			team.Status = 2
 			team.Time = time.Now().Add(time.Duration(-1*rand.Intn(60)) * time.Minute).Add(time.Duration(-1*rand.Intn(24)) * time.Hour)
 			team.Submit = true
-			team.Point = int8(rand.Intn(int(constant.PointMap[team.Route])))
+			team.Point = int8(rand.Intn(int(routeService.GetEndPoint(team.Route))))
 
 			if err := tx.Save(&team).Error; err != nil {
 				return err // 发生错误，回滚事务
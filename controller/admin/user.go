@@ -4,11 +4,11 @@ import (
 	"errors"
 	"sort"
 	"time"
-	"walk-server/constant"
 	"walk-server/global"
 	"walk-server/middleware"
 	"walk-server/model"
 	"walk-server/service/adminService"
+	"walk-server/service/routeService"
 	"walk-server/service/teamService"
 	"walk-server/service/userService"
 	"walk-server/utility"
@@ -43,6 +43,17 @@ func UserStatus(c *gin.Context) {
 		return
 	}
 
+	if len(teams) == 1 {
+		for id := range teams {
+			middleware.SetAuditTeam(c, uint(id))
+		}
+	}
+	if len(users) == 1 {
+		for openID := range users {
+			middleware.SetAuditPerson(c, openID)
+		}
+	}
+
 	// 验证用户权限
 	for _, person := range users {
 		team, exists := teams[person.TeamId]
@@ -79,6 +90,9 @@ func UserStatus(c *gin.Context) {
 		}
 		userService.Update(person)
 	}
+	for _, team := range teams {
+		adminService.NotifyDetailChanged(team.Route)
+	}
 
 	// 检查队伍是否已经没人在行
 	for _, user := range users {
@@ -131,10 +145,9 @@ func getUsersAndTeams(forms []UserStatusForm) (map[string]*model.Person, map[int
 }
 
 type GetTimeoutUsersData struct {
-	Minute int    `form:"minute" binding:"required"` // 超时时间
-	Route  uint8  `form:"route" binding:"required"`  // 路线
-	Type   uint8  `form:"type"`                      // 类型
-	Secret string `form:"secret" binding:"required"` // 密钥
+	Minute int   `form:"minute" binding:"required"` // 超时时间
+	Route  uint8 `form:"route" binding:"required"`  // 路线
+	Type   uint8 `form:"type"`                      // 类型
 }
 
 type User struct {
@@ -153,6 +166,7 @@ type User struct {
 	WalkStatus uint8     `json:"walk_status"` // 1 未出发，2 进行中，3 扫码成功，4 放弃，5 完成
 	Location   string    `json:"location"`
 	IsLost     bool      `json:"is_lost"`
+	LostReason string    `json:"lost_reason"`
 }
 
 type PointUsers struct {
@@ -167,8 +181,7 @@ func GetTimeoutUsers(c *gin.Context) {
 		utility.ResponseError(c, "参数错误")
 		return
 	}
-	if postForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
+	if !checkManageRoute(c, postForm.Route) {
 		return
 	}
 
@@ -226,7 +239,7 @@ func GetTimeoutUsers(c *gin.Context) {
 	for point, users := range teamMap {
 		results = append(results, PointUsers{
 			Point:    point,
-			Location: constant.GetPointName(postForm.Route, point),
+			Location: routeService.GetPointName(postForm.Route, point),
 			Users:    users,
 		})
 	}
@@ -254,8 +267,7 @@ func DownloadTimeoutUsers(c *gin.Context) {
 		utility.ResponseError(c, "参数错误")
 		return
 	}
-	if postForm.Secret != global.Config.GetString("server.secret") {
-		utility.ResponseError(c, "密码错误")
+	if !checkManageRoute(c, postForm.Route) {
 		return
 	}
 
@@ -276,7 +288,7 @@ func DownloadTimeoutUsers(c *gin.Context) {
 	)
 
 	// 预分配切片容量
-	point := constant.PointMap[postForm.Route]
+	point := uint8(routeService.GetEndPoint(postForm.Route))
 	headers := []string{"上个点位", "上个点位签到时间", "队伍编号", "队伍名称", "姓名", "队伍担当", "当前状态", "性别", "学号", "电话", "校区", "学院", "参与者类型"}
 
 	// 使用 map 预分配容量
@@ -290,7 +302,7 @@ func DownloadTimeoutUsers(c *gin.Context) {
 			continue
 		}
 
-		pointName := constant.GetPointName(postForm.Route, int8(i))
+		pointName := routeService.GetPointName(postForm.Route, int8(i))
 		pointUserMap[pointName] = make([][]any, 0, len(teams)*6)
 		points = append(points, pointName)
 
@@ -346,10 +358,8 @@ func DownloadTimeoutUsers(c *gin.Context) {
 	}
 
 	// 保存为 Excel 文件
-	fileName := constant.RouteMap[postForm.Route] + "路线未到人员名单.xlsx"
-	filePath := "./file/"
-	host := global.Config.GetString("frontend.url")
-	url, err := utility.CreateExcelFile(data, fileName, filePath, host)
+	fileName := routeService.GetRouteName(postForm.Route) + "路线未到人员名单.xlsx"
+	url, err := utility.CreateExcelFile(data, fileName)
 	if err != nil {
 		utility.ResponseError(c, "生成文件失败")
 		return
@@ -376,8 +386,9 @@ func buildUserData(person model.Person, team model.Team) User {
 		TeamName:   team.Name,
 		Status:     person.Status,
 		WalkStatus: person.WalkStatus,
-		Location:   constant.GetPointName(team.Route, team.Point),
+		Location:   routeService.GetPointName(team.Route, team.Point),
 		IsLost:     team.IsLost,
+		LostReason: team.LostReason,
 	}
 }
 
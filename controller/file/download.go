@@ -0,0 +1,32 @@
+package file
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Download 校验签名后下载生成的文件，表格以附件形式下载，海报等图片直接显示
+func Download(context *gin.Context) {
+	fullPath, ok := utility.VerifyFileURL(context.Param("path"), context.Query("expires"), context.Query("sign"))
+	if !ok {
+		utility.ResponseData(context, http.StatusForbidden, "链接无效或已过期", nil)
+		return
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil || info.IsDir() {
+		utility.ResponseData(context, http.StatusNotFound, "文件不存在或已被清理", nil)
+		return
+	}
+
+	context.Header("Cache-Control", "private, no-store")
+	if filepath.Ext(fullPath) == ".xlsx" {
+		context.FileAttachment(fullPath, filepath.Base(fullPath))
+		return
+	}
+	context.File(fullPath)
+}
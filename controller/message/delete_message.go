@@ -1,6 +1,8 @@
 package message
 
 import (
+	"log"
+	"walk-server/model"
 	"walk-server/utility"
 
 	"github.com/gin-gonic/gin"
@@ -10,6 +12,7 @@ type DeleteMessageData struct {
 	ID uint `json:"message_id"`
 }
 
+// DeleteMessage 删除消息，消息只会被归档，仍可以在已删除的消息中查看
 func DeleteMessage(context *gin.Context) {
 	// 获取 jwt 数据
 	jwtToken := context.GetHeader("Authorization")[7:]
@@ -23,8 +26,12 @@ func DeleteMessage(context *gin.Context) {
 		return
 	}
 
-	err = utility.DeleteMessage(deleteMessageData.ID, jwtData)
+	found, err := model.ArchiveMessage(jwtData.OpenID, deleteMessageData.ID)
 	if err != nil {
+		log.Println(err)
+		utility.ResponseError(context, "系统异常，请重试")
+		return
+	} else if !found {
 		utility.ResponseError(context, "access denied")
 		return
 	}
@@ -1,35 +1,127 @@
 package message
 
 import (
+	"log"
 	"walk-server/model"
 	"walk-server/utility"
 
 	"github.com/gin-gonic/gin"
 )
 
-// ListMessage 获取自己应该接收的邮件
+type ListMessageData struct {
+	Cursor   uint  `form:"cursor"`                                   // 上一页返回的 next_cursor，为空时从最新的消息开始
+	Limit    int   `form:"limit" binding:"min=0,max=100"`            // 每页数量，默认 20
+	Category uint8 `form:"category" binding:"omitempty,oneof=1 2 3"` // 1 系统，2 队伍变动，3 管理员广播，为空时不限
+	Unread   bool  `form:"unread"`                                   // 只获取未读消息
+	Archived bool  `form:"archived"`                                 // 获取已删除（归档）的消息
+}
+
+// ListMessage 按时间倒序分页获取自己的消息
 func ListMessage(context *gin.Context) {
 	// 获取 jwt 数据
 	jwtToken := context.GetHeader("Authorization")[7:]
 	jwtData, _ := utility.ParseToken(jwtToken)
 
-	messages, err := model.GetMessages(jwtData.OpenID)
+	var listMessageData ListMessageData
+	if err := context.ShouldBindQuery(&listMessageData); err != nil {
+		utility.ResponseError(context, "参数错误")
+		return
+	}
+	if listMessageData.Limit == 0 {
+		listMessageData.Limit = 20
+	}
+
+	filter := model.MessageFilter{
+		Category:   listMessageData.Category,
+		UnreadOnly: listMessageData.Unread,
+		Archived:   listMessageData.Archived,
+	}
+	messages, err := model.GetMessages(jwtData.OpenID, filter, listMessageData.Cursor, listMessageData.Limit)
 	if err != nil {
-		utility.ResponseError(context, "no result")
+		log.Println(err)
+		utility.ResponseError(context, "系统异常，请重试")
+		return
 	}
 
-	var messageRespData []gin.H
+	messageRespData := make([]gin.H, 0, len(messages))
 	for _, message := range messages {
 		messageRespData = append(messageRespData, gin.H{
-			"id": message.ID,
-			"sender_open_id": message.SenderOpenId,
+			"id":               message.ID,
+			"sender_open_id":   message.SenderOpenId,
 			"receiver_open_id": message.ReceiverOpenId,
-			"message": message.Message,
+			"message":          message.Message,
+			"category":         message.Category,
+			"read":             message.Read,
+			"read_at":          message.ReadAt,
+			"archived":         message.Archived,
+			"created_at":       message.CreatedAt,
 		})
 	}
-	
+
+	// 不足一页时说明没有更多消息
+	var nextCursor uint
+	if len(messages) == listMessageData.Limit {
+		nextCursor = messages[len(messages)-1].ID
+	}
+
+	utility.ResponseSuccess(context, gin.H{
+		"messages":    messageRespData,
+		"next_cursor": nextCursor,
+	})
+}
+
+// GetUnread 获取未读消息数量
+func GetUnread(context *gin.Context) {
+	// 获取 jwt 数据
+	jwtToken := context.GetHeader("Authorization")[7:]
+	jwtData, _ := utility.ParseToken(jwtToken)
+
+	counts, err := model.CountUnread(jwtData.OpenID)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(context, "系统异常，请重试")
+		return
+	}
+
+	var total int64
+	for _, count := range counts {
+		total += count
+	}
+	utility.ResponseSuccess(context, gin.H{
+		"unread": total,
+		"categories": gin.H{
+			"system":    counts[model.MessageSystem],
+			"team":      counts[model.MessageTeam],
+			"broadcast": counts[model.MessageBroadcast],
+		},
+	})
+}
+
+type ReadMessageData struct {
+	IDs      []uint `json:"message_ids" binding:"max=500"`            // 为空时标记全部消息
+	Category uint8  `json:"category" binding:"omitempty,oneof=1 2 3"` // 只标记该分类的消息
+}
+
+// ReadMessage 批量标记消息为已读
+func ReadMessage(context *gin.Context) {
+	// 获取 jwt 数据
+	jwtToken := context.GetHeader("Authorization")[7:]
+	jwtData, _ := utility.ParseToken(jwtToken)
+
+	var readMessageData ReadMessageData
+	if err := context.ShouldBindJSON(&readMessageData); err != nil {
+		utility.ResponseError(context, "参数错误")
+		return
+	}
+
+	n, err := model.MarkRead(jwtData.OpenID, readMessageData.IDs, readMessageData.Category)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(context, "系统异常，请重试")
+		return
+	}
 
 	utility.ResponseSuccess(context, gin.H{
-		"messages": messageRespData,
+		"count": n,
 	})
 }
@@ -1,20 +1,16 @@
 package poster
 
 import (
+	"errors"
+	"log"
 	"walk-server/model"
+	"walk-server/service/posterService"
 	"walk-server/utility"
 
 	"github.com/gin-gonic/gin"
 )
 
-var teamRouteMap = map[uint8]string{
-	1: "朝晖全程",
-	2: "屏峰半程",
-	3: "屏峰全程",
-	4: "莫干山半程",
-	5: "莫干山全程",
-}
-
+// GetPoster 获取队伍海报，format=image 时直接返回 PNG 图片，否则返回图片链接
 func GetPoster(context *gin.Context) {
 	// 获取 jwt 数据
 	jwtToken := context.GetHeader("Authorization")[7:]
@@ -28,21 +24,29 @@ func GetPoster(context *gin.Context) {
 		return
 	}
 
-	// 获取团队成员
-	captain, members := model.GetPersonsInTeam(user.TeamId)
-	var memberNames []string
-	memberNames = append(memberNames, captain.Name)
-	for _, member := range members {
-		memberNames = append(memberNames, member.Name)
+	poster, err := posterService.Get(team)
+	if errors.Is(err, utility.ErrPosterFont) {
+		utility.ResponseError(context, "海报功能暂不可用")
+		return
 	}
-
-	imgUrl, err := utility.Poster(teamRouteMap[team.Route], team.Name, team.Slogan, int(team.Num), memberNames)
 	if err != nil {
+		log.Println(err)
 		utility.ResponseError(context, "海报生成错误")
 		return
 	}
 
+	if context.Query("format") == "image" {
+		context.Header("Cache-Control", "private, max-age=300")
+		context.File(poster.Path)
+		return
+	}
+	url, err := poster.URL()
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(context, "海报生成错误")
+		return
+	}
 	utility.ResponseSuccess(context, gin.H{
-		"img_url": imgUrl,
+		"img_url": url,
 	})
 }
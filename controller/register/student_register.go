@@ -39,7 +39,7 @@ func StudentRegister(context *gin.Context) {
 	}
 
 	var user model.Person
-	result := global.DB.Where("stu_id =? Or identity = ? Or tel = ?", postData.StuID, postData.ID, postData.Contact.Tel).Take(&user)
+	result := global.DB.Where("stu_id =? Or identity_hash = ? Or tel_hash = ?", postData.StuID, model.IdentityHash(postData.ID), model.TelHash(postData.Contact.Tel)).Take(&user)
 	if result.RowsAffected != 0 {
 		utility.ResponseError(context, "已有身份信息，请检查是否填写错误")
 		return
@@ -84,7 +84,7 @@ func StudentRegister(context *gin.Context) {
 		StuId:      postData.StuID,
 		Status:     0,
 		College:    postData.College,
-		Identity:   postData.ID,
+		Identity:   model.NormalizeIdentity(postData.ID),
 		Campus:     postData.Campus,
 		Qq:         postData.Contact.QQ,
 		Wechat:     postData.Contact.Wechat,
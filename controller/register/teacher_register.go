@@ -38,7 +38,7 @@ func TeacherRegister(context *gin.Context) {
 	}
 
 	var user model.Person
-	result := global.DB.Where("stu_id =? Or identity = ? Or tel = ?", postData.StuID, postData.ID, postData.Contact.Tel).Take(&user)
+	result := global.DB.Where("stu_id =? Or identity_hash = ? Or tel_hash = ?", postData.StuID, model.IdentityHash(postData.ID), model.TelHash(postData.Contact.Tel)).Take(&user)
 	if result.RowsAffected != 0 {
 		utility.ResponseError(context, "已有身份信息，请检查是否填写错误")
 		return
@@ -81,7 +81,7 @@ func TeacherRegister(context *gin.Context) {
 		StuId:      postData.StuID,
 		Name:       info.Name,
 		Gender:     gender,
-		Identity:   postData.ID,
+		Identity:   model.NormalizeIdentity(postData.ID),
 		College:    info.College,
 		Status:     0,
 		Qq:         postData.Contact.QQ,
@@ -5,6 +5,8 @@ import (
 	"log"
 	"walk-server/global"
 	"walk-server/model"
+	"walk-server/service/policyService"
+	"walk-server/service/posterService"
 	"walk-server/utility"
 
 	"github.com/gin-gonic/gin"
@@ -28,11 +30,6 @@ func AddMember(context *gin.Context) {
 
 	var team model.Team
 	global.DB.Where("id = ?", person.TeamId).Take(&team)
-	teamSubmitted, _ := global.Rdb.SIsMember(global.Rctx, "teams", team.ID).Result()
-	if teamSubmitted && team.Num >= 6 {
-		utility.ResponseError(context, "队伍人数不能超过6")
-		return
-	}
 
 	// 读取 Get 参数
 	var newMember model.Person
@@ -56,8 +53,8 @@ func AddMember(context *gin.Context) {
 		utility.ResponseError(context, "该用户在其他队伍中")
 		return
 	}
-	if person.Type == 1 && newMember.Type == 2 {
-		utility.ResponseError(context, "该用户为教师，无法加入学生队伍")
+	if err := policyService.GetPolicy(team.Route).CheckJoin(&team, person, &newMember); err != nil {
+		utility.ResponseError(context, err.Error())
 		return
 	}
 
@@ -82,6 +79,8 @@ func AddMember(context *gin.Context) {
 		return
 	}
 
+	posterService.Invalidate(team.ID)
+
 	// 通知
 	utility.SendMessage("你被"+person.Name+"添加至团队"+team.Name, nil, &newMember)
 
@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"walk-server/global"
 	"walk-server/model"
+	"walk-server/service/policyService"
+	"walk-server/service/posterService"
 	"walk-server/utility"
 )
 
@@ -71,10 +73,10 @@ func ChangeCaptain(context *gin.Context) {
 		return
 	}
 
-	if person.Type != 1 && newCaptain.Type == 1 {
-		utility.ResponseError(context, "无法将队长移交给学生")
+	// 检查能否移交队长
+	if err := policyService.GetPolicy(team.Route).CheckCaptain(person, newCaptain); err != nil {
+		utility.ResponseError(context, err.Error())
 		return
-
 	}
 
 	err = global.DB.Transaction(func(tx *gorm.DB) error {
@@ -101,5 +103,6 @@ func ChangeCaptain(context *gin.Context) {
 		utility.ResponseError(context, "服务异常，请重试")
 		return
 	}
+	posterService.Invalidate(team.ID)
 	utility.ResponseSuccess(context, nil)
 }
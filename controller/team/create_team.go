@@ -5,6 +5,7 @@ import (
 	"time"
 	"walk-server/global"
 	"walk-server/model"
+	"walk-server/service/routeService"
 	"walk-server/utility"
 
 	"gorm.io/gorm"
@@ -16,9 +17,10 @@ import (
 type CreateTeamData struct {
 	Name       string `json:"name" binding:"required"`
 	Route      uint8  `json:"route" binding:"required"`
-	Password   string `json:"password" binding:"required"`
+	Password   string `json:"password"`
 	Slogan     string `json:"slogan" binding:"required"`
 	AllowMatch *bool  `json:"allow_match" binding:"required"`
+	InviteOnly bool   `json:"invite_only"` // 为 true 时只能通过邀请加入，不需要密码
 }
 
 func CreateTeam(context *gin.Context) {
@@ -34,8 +36,12 @@ func CreateTeam(context *gin.Context) {
 		return
 	}
 
-	if createTeamData.Route > 6 {
-		utility.ResponseError(context, "参数错误")
+	if !routeService.Exists(createTeamData.Route) {
+		utility.ResponseError(context, "路线不存在")
+		return
+	}
+	if !createTeamData.InviteOnly && createTeamData.Password == "" {
+		utility.ResponseError(context, "请设置队伍密码")
 		return
 	}
 
@@ -57,6 +63,7 @@ func CreateTeam(context *gin.Context) {
 		Num:        1,
 		AllowMatch: *createTeamData.AllowMatch,
 		Password:   createTeamData.Password,
+		InviteOnly: createTeamData.InviteOnly,
 		Captain:    person.OpenId,
 		Route:      createTeamData.Route,
 		Slogan:     createTeamData.Slogan,
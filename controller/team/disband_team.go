@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"walk-server/global"
 	"walk-server/model"
+	"walk-server/service/posterService"
+	"walk-server/service/teamService"
 	"walk-server/utility"
 
 	"github.com/gin-gonic/gin"
@@ -71,6 +73,8 @@ func DisbandTeam(context *gin.Context) {
 		return
 	}
 
+	teamService.LeaveWaitlist(teamID)
+	posterService.Invalidate(team.ID)
 	utility.SendMessageToMembers(team.Name+"已经被解散", captain, members)
 
 	utility.ResponseSuccess(context, nil)
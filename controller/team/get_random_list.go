@@ -3,6 +3,7 @@ package team
 import (
 	"walk-server/global"
 	"walk-server/model"
+	"walk-server/service/policyService"
 	"walk-server/utility"
 
 	"github.com/gin-gonic/gin"
@@ -39,29 +40,32 @@ func GetRandomList(context *gin.Context) {
 	var teams []model.Team
 	var teamList []gin.H
 
-	// 先查找 3 人以下的团队
+	// 按队伍还差的人数分三档查找，人数上限由路线的组队规则决定
+	maxMembers := int(policyService.GetPolicy(uint8(getRandomListData.Route)).MaxMembers)
+
+	// 先查找还差 3 人及以上的团队
 	global.DB.Model(&model.Team{}).
-		Where("route = ? AND num <= 3 AND allow_match = 1", getRandomListData.Route).
+		Where("route = ? AND num <= ? AND allow_match = 1", getRandomListData.Route, maxMembers-3).
 		Order("RAND()").
 		Limit(3).
 		Find(&teams)
 	teamNum1 := len(teams)
 	teamList = addTeamData(teamList, &teams)
 
-	// 查找 4 人团队
+	// 查找还差 2 人的团队
 	teams = teams[:0]
 	global.DB.Model(&model.Team{}).
-		Where("route = ? AND num = 4 AND allow_match = 1", getRandomListData.Route).
+		Where("route = ? AND num = ? AND allow_match = 1", getRandomListData.Route, maxMembers-2).
 		Order("RAND()").
 		Limit(4 - teamNum1).
 		Find(&teams)
 	teamNum2 := len(teams)
 	teamList = addTeamData(teamList, &teams)
 
-	// 查找 5 人团队
+	// 查找还差 1 人的团队
 	teams = teams[:0]
 	global.DB.Model(&model.Team{}).
-		Where("route = ? AND num = 5 AND allow_match = 1", getRandomListData.Route).
+		Where("route = ? AND num = ? AND allow_match = 1", getRandomListData.Route, maxMembers-1).
 		Order("RAND()").
 		Limit(5 - teamNum2 - teamNum1).
 		Find(&teams)
@@ -72,6 +72,7 @@ func GetTeamInfo(context *gin.Context) {
 		"password":    team.Password,
 		"submitted":   teamSubmitted,
 		"allow_match": team.AllowMatch,
+		"invite_only": team.InviteOnly,
 		"slogan":      team.Slogan,
 		"point":       team.Point,
 		"status":      team.Status,
@@ -0,0 +1,214 @@
+package team
+
+import (
+	"errors"
+	"log"
+	"net/url"
+	"time"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errInvitationInvalid = errors.New("invitation invalid")
+
+// CreateInviteData 生成邀请时接收的信息类型
+type CreateInviteData struct {
+	ExpireHours uint `json:"expire_hours" binding:"required,min=1,max=168"` // 有效小时数，最长 7 天
+	MaxUses     uint `json:"max_uses" binding:"required,min=1,max=5"`       // 最多可以使用的次数
+}
+
+// CreateInvite 队长生成邀请链接
+func CreateInvite(context *gin.Context) {
+	// 获取 jwt 数据
+	jwtToken := context.GetHeader("Authorization")[7:]
+	jwtData, _ := utility.ParseToken(jwtToken)
+
+	var createInviteData CreateInviteData
+	if err := context.ShouldBindJSON(&createInviteData); err != nil {
+		utility.ResponseError(context, "参数错误")
+		return
+	}
+
+	// 查找用户
+	person, _ := model.GetPerson(jwtData.OpenID)
+	if person.Status == 0 {
+		utility.ResponseError(context, "请先加入队伍")
+		return
+	} else if person.Status == 1 {
+		utility.ResponseError(context, "只有队长可以邀请")
+		return
+	}
+
+	invitation := model.Invitation{
+		TeamID:    uint(person.TeamId),
+		Creator:   person.OpenId,
+		MaxUses:   createInviteData.MaxUses,
+		ExpiresAt: time.Now().Add(time.Duration(createInviteData.ExpireHours) * time.Hour),
+	}
+	if err := model.InsertInvitation(&invitation); err != nil {
+		log.Println(err)
+		utility.ResponseError(context, "系统异常，请重试")
+		return
+	}
+
+	token, err := utility.GenerateInviteToken(&utility.InviteData{
+		InviteID: invitation.ID,
+		TeamID:   invitation.TeamID,
+	}, invitation.ExpiresAt)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(context, "系统异常，请重试")
+		return
+	}
+
+	utility.ResponseSuccess(context, gin.H{
+		"invitation": invitation,
+		"token":      token,
+		"link":       global.Config.GetString("frontend.url") + "/invite?token=" + url.QueryEscape(token),
+	})
+}
+
+// ListInvite 队长查看队伍的所有邀请
+func ListInvite(context *gin.Context) {
+	// 获取 jwt 数据
+	jwtToken := context.GetHeader("Authorization")[7:]
+	jwtData, _ := utility.ParseToken(jwtToken)
+
+	// 查找用户
+	person, _ := model.GetPerson(jwtData.OpenID)
+	if person.Status == 0 {
+		utility.ResponseError(context, "请先加入队伍")
+		return
+	} else if person.Status == 1 {
+		utility.ResponseError(context, "没有查看的权限")
+		return
+	}
+
+	invitations, err := model.GetInvitations(uint(person.TeamId))
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(context, "系统异常，请重试")
+		return
+	}
+
+	var inviteData []gin.H
+	for _, invitation := range invitations {
+		inviteData = append(inviteData, gin.H{
+			"id":         invitation.ID,
+			"max_uses":   invitation.MaxUses,
+			"used":       invitation.Used,
+			"revoked":    invitation.Revoked,
+			"valid":      invitation.Valid(),
+			"expires_at": invitation.ExpiresAt,
+			"created_at": invitation.CreatedAt,
+		})
+	}
+
+	utility.ResponseSuccess(context, gin.H{
+		"invitations": inviteData,
+	})
+}
+
+// RevokeInviteData 撤销邀请时接收的信息类型
+type RevokeInviteData struct {
+	ID uint `json:"id" binding:"required"`
+}
+
+// RevokeInvite 队长撤销邀请
+func RevokeInvite(context *gin.Context) {
+	// 获取 jwt 数据
+	jwtToken := context.GetHeader("Authorization")[7:]
+	jwtData, _ := utility.ParseToken(jwtToken)
+
+	var revokeInviteData RevokeInviteData
+	if err := context.ShouldBindJSON(&revokeInviteData); err != nil {
+		utility.ResponseError(context, "参数错误")
+		return
+	}
+
+	// 查找用户
+	person, _ := model.GetPerson(jwtData.OpenID)
+	if person.Status == 0 {
+		utility.ResponseError(context, "请先加入队伍")
+		return
+	} else if person.Status == 1 {
+		utility.ResponseError(context, "没有修改的权限")
+		return
+	}
+
+	found, err := model.RevokeInvitation(uint(person.TeamId), revokeInviteData.ID)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(context, "系统异常，请重试")
+		return
+	} else if !found {
+		utility.ResponseError(context, "邀请不存在")
+		return
+	}
+
+	utility.ResponseSuccess(context, nil)
+}
+
+// JoinByInviteData 通过邀请加入团队时接收的信息类型
+type JoinByInviteData struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// JoinByInvite 通过邀请链接加入团队
+func JoinByInvite(context *gin.Context) {
+	// 获取 jwt 数据
+	jwtToken := context.GetHeader("Authorization")[7:]
+	jwtData, _ := utility.ParseToken(jwtToken)
+
+	var joinByInviteData JoinByInviteData
+	if err := context.ShouldBindJSON(&joinByInviteData); err != nil {
+		utility.ResponseError(context, "参数错误")
+		return
+	}
+
+	inviteData, err := utility.ParseInviteToken(joinByInviteData.Token)
+	if err != nil {
+		utility.ResponseError(context, "邀请已失效")
+		return
+	}
+
+	// 从数据库中读取用户信息
+	person, _ := model.GetPerson(jwtData.OpenID)
+
+	if person.Status != 0 { // 如果在一个团队中
+		utility.ResponseError(context, "请退出或解散原来的团队")
+		return
+	}
+
+	if person.JoinOp == 0 { // 加入次数用完了
+		utility.ResponseError(context, "没有加入次数了")
+		return
+	}
+
+	invitation, err := model.GetInvitation(inviteData.InviteID)
+	if err != nil || invitation.TeamID != inviteData.TeamID || !invitation.Valid() {
+		utility.ResponseError(context, "邀请已失效")
+		return
+	}
+
+	var team model.Team
+	result := global.DB.Where("id = ?", invitation.TeamID).Take(&team)
+	if result.RowsAffected == 0 {
+		utility.ResponseError(context, "找不到团队")
+		return
+	}
+
+	joinTeam(context, person, &team, func(tx *gorm.DB) error {
+		ok, err := model.TxUseInvitation(tx, invitation.ID)
+		if err != nil {
+			return err
+		} else if !ok {
+			return errInvitationInvalid
+		}
+		return nil
+	}, person.Name+"通过邀请加入了团队")
+}
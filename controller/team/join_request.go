@@ -0,0 +1,218 @@
+package team
+
+import (
+	"errors"
+	"log"
+	"walk-server/model"
+	"walk-server/service/teamService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errJoinRequestHandled = errors.New("join request handled")
+
+// ListJoinRequest 队长获取待处理的加入申请
+func ListJoinRequest(context *gin.Context) {
+	// 获取 jwt 数据
+	jwtToken := context.GetHeader("Authorization")[7:]
+	jwtData, _ := utility.ParseToken(jwtToken)
+
+	// 查找用户
+	person, _ := model.GetPerson(jwtData.OpenID)
+	if person.Status == 0 {
+		utility.ResponseError(context, "请先加入队伍")
+		return
+	} else if person.Status == 1 {
+		utility.ResponseError(context, "没有查看的权限")
+		return
+	}
+
+	requests, err := model.GetPendingJoinRequests(uint(person.TeamId))
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(context, "系统异常，请重试")
+		return
+	}
+
+	var requestData []gin.H
+	for _, request := range requests {
+		if teamService.IsJoinRequestExpired(&request) {
+			continue
+		}
+		applicant, err := model.GetPerson(request.OpenId)
+		if err != nil {
+			continue
+		}
+		requestData = append(requestData, gin.H{
+			"id":         request.ID,
+			"source":     request.Source,
+			"created_at": request.CreatedAt,
+			"name":       applicant.Name,
+			"gender":     applicant.Gender,
+			"campus":     applicant.Campus,
+			"college":    applicant.College,
+			"type":       applicant.Type,
+		})
+	}
+
+	utility.ResponseSuccess(context, gin.H{
+		"requests": requestData,
+	})
+}
+
+// HandleJoinRequestData 处理加入申请时接收的信息类型
+type HandleJoinRequestData struct {
+	ID uint `json:"id" binding:"required"`
+}
+
+// ApproveJoinRequest 队长通过加入申请，此时才消耗申请人的加入次数
+func ApproveJoinRequest(context *gin.Context) {
+	captain, request, team, ok := getHandledJoinRequest(context)
+	if !ok {
+		return
+	}
+
+	// 申请人在等待期间可能已经加入或创建了其他队伍
+	applicant, err := model.GetPerson(request.OpenId)
+	if err != nil || applicant.Status != 0 || applicant.JoinOp == 0 {
+		model.HandleJoinRequest(request.ID, model.JoinRequestCancelled)
+		utility.ResponseError(context, "对方已无法加入队伍，申请已失效")
+		return
+	}
+
+	joined := joinTeam(context, applicant, team, func(tx *gorm.DB) error {
+		ok, err := model.TxHandleJoinRequest(tx, request.ID, model.JoinRequestApproved)
+		if err != nil {
+			return err
+		} else if !ok {
+			return errJoinRequestHandled
+		}
+		return nil
+	}, applicant.Name+"加入了团队")
+	if joined {
+		utility.SendMessage("你加入"+team.Name+"的申请已通过", captain, applicant)
+	}
+}
+
+// RejectJoinRequest 队长拒绝加入申请
+func RejectJoinRequest(context *gin.Context) {
+	captain, request, team, ok := getHandledJoinRequest(context)
+	if !ok {
+		return
+	}
+
+	handled, err := model.HandleJoinRequest(request.ID, model.JoinRequestRejected)
+	if err != nil {
+		log.Println(err)
+		utility.ResponseError(context, "系统异常，请重试")
+		return
+	} else if !handled {
+		utility.ResponseError(context, "申请已被处理")
+		return
+	}
+
+	if applicant, err := model.GetPerson(request.OpenId); err == nil {
+		utility.SendMessage("你加入"+team.Name+"的申请被拒绝了", captain, applicant)
+	}
+
+	utility.ResponseSuccess(context, nil)
+}
+
+// getHandledJoinRequest 获取队长要处理的申请，申请不存在、不属于该队伍或已处理时返回 false
+func getHandledJoinRequest(context *gin.Context) (*model.Person, *model.JoinRequest, *model.Team, bool) {
+	// 获取 jwt 数据
+	jwtToken := context.GetHeader("Authorization")[7:]
+	jwtData, _ := utility.ParseToken(jwtToken)
+
+	var handleData HandleJoinRequestData
+	if err := context.ShouldBindJSON(&handleData); err != nil {
+		utility.ResponseError(context, "参数错误")
+		return nil, nil, nil, false
+	}
+
+	// 查找用户
+	person, _ := model.GetPerson(jwtData.OpenID)
+	if person.Status == 0 {
+		utility.ResponseError(context, "请先加入队伍")
+		return nil, nil, nil, false
+	} else if person.Status == 1 {
+		utility.ResponseError(context, "没有修改的权限")
+		return nil, nil, nil, false
+	}
+
+	request, err := model.GetJoinRequest(handleData.ID)
+	if err != nil || request.TeamID != uint(person.TeamId) {
+		utility.ResponseError(context, "申请不存在")
+		return nil, nil, nil, false
+	} else if request.Status != model.JoinRequestPending {
+		utility.ResponseError(context, "申请已被处理")
+		return nil, nil, nil, false
+	} else if teamService.IsJoinRequestExpired(request) {
+		utility.ResponseError(context, "申请已过期")
+		return nil, nil, nil, false
+	}
+
+	team, err := teamService.GetTeamByID(request.TeamID)
+	if err != nil {
+		utility.ResponseError(context, "找不到团队")
+		return nil, nil, nil, false
+	}
+
+	return person, request, team, true
+}
+
+// GetMyJoinRequest 申请人查看自己待处理的申请
+func GetMyJoinRequest(context *gin.Context) {
+	// 获取 jwt 数据
+	jwtToken := context.GetHeader("Authorization")[7:]
+	jwtData, _ := utility.ParseToken(jwtToken)
+
+	request := model.GetPendingJoinRequest(jwtData.OpenID)
+	if request == nil || teamService.IsJoinRequestExpired(request) {
+		utility.ResponseSuccess(context, gin.H{
+			"pending": false,
+		})
+		return
+	}
+
+	team, err := teamService.GetTeamByID(request.TeamID)
+	if err != nil {
+		utility.ResponseSuccess(context, gin.H{
+			"pending": false,
+		})
+		return
+	}
+
+	utility.ResponseSuccess(context, gin.H{
+		"pending":    true,
+		"id":         request.ID,
+		"team_id":    team.ID,
+		"team_name":  team.Name,
+		"source":     request.Source,
+		"created_at": request.CreatedAt,
+		"expires_at": request.CreatedAt.Add(teamService.JoinRequestTimeout()),
+	})
+}
+
+// CancelJoinRequest 申请人取消自己待处理的申请
+func CancelJoinRequest(context *gin.Context) {
+	// 获取 jwt 数据
+	jwtToken := context.GetHeader("Authorization")[7:]
+	jwtData, _ := utility.ParseToken(jwtToken)
+
+	request := model.GetPendingJoinRequest(jwtData.OpenID)
+	if request == nil {
+		utility.ResponseError(context, "没有待处理的申请")
+		return
+	}
+
+	if _, err := model.HandleJoinRequest(request.ID, model.JoinRequestCancelled); err != nil {
+		log.Println(err)
+		utility.ResponseError(context, "系统异常，请重试")
+		return
+	}
+
+	utility.ResponseSuccess(context, nil)
+}
@@ -1,10 +1,14 @@
 package team
 
 import (
+	"errors"
 	"gorm.io/gorm"
+	"log"
 	"strconv"
 	"walk-server/global"
 	"walk-server/model"
+	"walk-server/service/policyService"
+	"walk-server/service/posterService"
 	"walk-server/utility"
 
 	"github.com/gin-gonic/gin"
@@ -47,35 +51,84 @@ func JoinTeam(context *gin.Context) {
 	if result.RowsAffected == 0 {
 		utility.ResponseError(context, "找不到团队")
 		return
+	} else if team.InviteOnly {
+		utility.ResponseError(context, "该队伍仅允许通过邀请加入")
+		return
 	} else if team.Password != joinTeamData.Password {
 		utility.ResponseError(context, "密码错误")
 		return
 	}
 
+	requestJoin(context, person, &team, model.JoinSourcePassword)
+}
+
+// checkJoinable 检查用户能否加入队伍，不能加入时返回错误信息，可以加入时返回队伍原来的队长和队员
+func checkJoinable(context *gin.Context, person *model.Person, team *model.Team) (model.Person, []model.Person, bool) {
 	teamID := strconv.Itoa(int(team.ID))
 	teamSubmitted, _ := global.Rdb.SIsMember(global.Rctx, "teams", teamID).Result()
 	if teamSubmitted {
 		utility.ResponseError(context, "该队伍已提交，无法加入")
-		return
+		return model.Person{}, nil, false
 	}
 
-	// 队伍上限 6 人
-	if team.Num >= 6 {
-		utility.ResponseError(context, "队伍人数到达上限")
+	// 获取这个团队原来的队长和队员
+	captain, members := model.GetPersonsInTeam(int(team.ID))
+
+	if err := policyService.GetPolicy(team.Route).CheckJoin(team, &captain, person); err != nil {
+		utility.ResponseError(context, err.Error())
+		return model.Person{}, nil, false
+	}
+
+	return captain, members, true
+}
+
+// requestJoin 创建加入申请，等待队长审批
+func requestJoin(context *gin.Context, person *model.Person, team *model.Team, source uint8) {
+	if model.GetPendingJoinRequest(person.OpenId) != nil {
+		utility.ResponseError(context, "已有待处理的申请，请等待队长处理或取消申请")
 		return
 	}
 
-	// 获取这个团队原来的队长和队员
-	captain, members := model.GetPersonsInTeam(int(team.ID))
+	captain, _, ok := checkJoinable(context, person, team)
+	if !ok {
+		return
+	}
 
-	if captain.Type == 1 && person.Type == 2 {
-		utility.ResponseError(context, "您是教师，无法加入学生队伍")
+	request := model.JoinRequest{
+		TeamID: team.ID,
+		OpenId: person.OpenId,
+		Source: source,
+		Status: model.JoinRequestPending,
+	}
+	if err := model.InsertJoinRequest(&request); err != nil {
+		log.Println(err)
+		utility.ResponseError(context, "系统异常，请重试")
 		return
 	}
 
-	err = global.DB.Transaction(func(tx *gorm.DB) error {
+	utility.SendMessage(person.Name+"申请加入队伍，请及时处理", nil, &captain)
+
+	utility.ResponseSuccess(context, gin.H{
+		"request_id": request.ID,
+	})
+}
+
+// joinTeam 将用户加入队伍，consume 不为 nil 时在同一事务中执行（如消耗邀请次数），加入失败时返回 false
+func joinTeam(context *gin.Context, person *model.Person, team *model.Team, consume func(tx *gorm.DB) error, message string) bool {
+	captain, members, ok := checkJoinable(context, person, team)
+	if !ok {
+		return false
+	}
+
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		if consume != nil {
+			if err := consume(tx); err != nil {
+				return err
+			}
+		}
+
 		// 队伍成员数量加一
-		if err := tx.Model(&team).Update("num", team.Num+1).Error; err != nil {
+		if err := tx.Model(team).Update("num", team.Num+1).Error; err != nil {
 			return err
 		}
 
@@ -89,9 +142,23 @@ func JoinTeam(context *gin.Context) {
 
 		return nil
 	})
+	if errors.Is(err, errInvitationInvalid) {
+		utility.ResponseError(context, "邀请已失效")
+		return false
+	} else if errors.Is(err, errJoinRequestHandled) {
+		utility.ResponseError(context, "申请已被处理")
+		return false
+	} else if err != nil {
+		log.Println(err)
+		utility.ResponseError(context, "系统异常，请重试")
+		return false
+	}
+
+	posterService.Invalidate(team.ID)
 
 	// 加入成功以后发送消息给所有的用户
-	utility.SendMessageToTeam(person.Name+"加入了团队", captain, members)
+	utility.SendMessageToTeam(message, captain, members)
 
 	utility.ResponseSuccess(context, nil)
+	return true
 }
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"walk-server/global"
 	"walk-server/model"
+	"walk-server/service/posterService"
 	"walk-server/utility"
 
 	"github.com/gin-gonic/gin"
@@ -58,6 +59,7 @@ func LeaveTeam(context *gin.Context) {
 		return
 	}
 
+	posterService.Invalidate(team.ID)
 	captain, members := model.GetPersonsInTeam(int(team.ID)) // 获取这个人退出了以后团队中的所有成员
 	utility.SendMessageToTeam(person.Name+"已经离开了队伍", captain, members)
 
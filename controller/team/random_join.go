@@ -1,7 +1,6 @@
 package team
 
 import (
-	"gorm.io/gorm"
 	"strconv"
 	"walk-server/global"
 	"walk-server/model"
@@ -41,9 +40,13 @@ func RandomJoin(context *gin.Context) {
 		return
 	}
 
-	// 加入队伍
+	// 申请加入队伍
 	var team model.Team
-	global.DB.Where("id = ?", randomJoinData.ID).Take(&team)
+	result := global.DB.Where("id = ?", randomJoinData.ID).Take(&team)
+	if result.RowsAffected == 0 {
+		utility.ResponseError(context, "找不到团队")
+		return
+	}
 	teamID := strconv.Itoa(int(team.ID))
 	teamSubmitted, _ := global.Rdb.SIsMember(global.Rctx, "teams", teamID).Result()
 	if teamSubmitted {
@@ -51,38 +54,10 @@ func RandomJoin(context *gin.Context) {
 		return
 	}
 
-	if team.Num >= 6 || !team.AllowMatch {
-		utility.ResponseError(context, "队伍刚刚满人了或者关闭了随机组队")
-		return
-	}
-
-	// 获取这个团队原来的队长和队员
-	captain, members := model.GetPersonsInTeam(int(team.ID))
-
-	if captain.Type == 1 && person.Type == 2 {
-		utility.ResponseError(context, "您是教师，无法加入学生队伍")
+	if !team.AllowMatch {
+		utility.ResponseError(context, "队伍刚刚关闭了随机组队")
 		return
 	}
 
-	err = global.DB.Transaction(func(tx *gorm.DB) error {
-		// 队伍成员数量加一
-		if err := tx.Model(&team).Update("num", team.Num+1).Error; err != nil {
-			return err
-		}
-
-		// 更新加入成员的信息
-		person.Status = 1
-		person.JoinOp--
-		person.TeamId = int(team.ID)
-		if err := model.TxUpdatePerson(tx, person); err != nil {
-			return err
-		}
-
-		return nil
-	})
-
-	// 加入成功以后发送消息给所有的用户
-	utility.SendMessageToTeam(person.Name+"通过随机组队加入了队伍", captain, members)
-
-	utility.ResponseSuccess(context, nil)
+	requestJoin(context, person, &team, model.JoinSourceRandom)
 }
@@ -5,6 +5,8 @@ import (
 	"log"
 	"walk-server/global"
 	"walk-server/model"
+	"walk-server/service/policyService"
+	"walk-server/service/posterService"
 	"walk-server/utility"
 
 	"github.com/gin-gonic/gin"
@@ -29,8 +31,8 @@ func RemoveMember(context *gin.Context) {
 	var team model.Team
 	global.DB.Where("id = ?", person.TeamId).Take(&team)
 	teamSubmitted, _ := global.Rdb.SIsMember(global.Rctx, "teams", team.ID).Result()
-	if teamSubmitted && team.Num <= 4 {
-		utility.ResponseError(context, "队伍人数不能低于4")
+	if err := policyService.GetPolicy(team.Route).CheckLeave(&team, teamSubmitted); err != nil {
+		utility.ResponseError(context, err.Error())
 		return
 	}
 
@@ -67,6 +69,8 @@ func RemoveMember(context *gin.Context) {
 		return
 	}
 
+	posterService.Invalidate(team.ID)
+
 	// 通知
 	utility.SendMessage("你被团队"+team.Name+"踢出", nil, personRemoved)
 
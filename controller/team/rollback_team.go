@@ -1,9 +1,12 @@
 package team
 
 import (
+	"log"
 	"strconv"
 	"walk-server/global"
 	"walk-server/model"
+	"walk-server/service/quotaService"
+	"walk-server/service/teamService"
 	"walk-server/utility"
 
 	"github.com/gin-gonic/gin"
@@ -35,13 +38,20 @@ func RollBackTeam(context *gin.Context) {
 		return
 	}
 
-	// 删除队伍的提交状态
+	// 删除队伍的提交状态，名额退回提交时占用的那一天
 	global.Rdb.SRem(global.Rctx, "teams", teamID)
-	var dailyRouteKey string
+	dailyRouteKey, err := global.Rdb.HGet(global.Rctx, quotaService.SubmittedKey, teamID).Result()
+	if err != nil {
+		dailyRouteKey = quotaService.Key(utility.GetCurrentDate(), team.Route)
+	}
+	global.Rdb.HDel(global.Rctx, quotaService.SubmittedKey, teamID)
+	if err := teamService.SetSubmitted(teamID, false); err != nil {
+		log.Println(err)
+	}
 	if person.Type == 1 {
-		dailyRoute := utility.GetCurrentDate()*10 + team.Route
-		dailyRouteKey = strconv.Itoa(int(dailyRoute))
 		global.Rdb.Incr(global.Rctx, dailyRouteKey)
+		// 退回的名额优先给候补队伍
+		teamService.PromoteWaitlist(dailyRouteKey)
 	}
 	utility.ResponseSuccess(context, nil)
 }
@@ -5,31 +5,14 @@ import (
 	"strconv"
 	"walk-server/global"
 	"walk-server/model"
+	"walk-server/service/policyService"
+	"walk-server/service/quotaService"
+	"walk-server/service/teamService"
 	"walk-server/utility"
 
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
 )
 
-// 编写Lua脚本 - 判断是否已经提交、判断是否达到上限、提交后将剩余数量减一并记录提交的团队id
-var submit = redis.NewScript(`
-local teamID = KEYS[1];
-local dailyRouteKey = KEYS[2];
-
-local num = redis.call("get", dailyRouteKey);
-if not num or tonumber(num) <= 0 then
-	return 2;
-end
-
-local added = redis.call("SAdd", "teams", teamID);
-if added == 0 then
-	return 1;
-end
-
-redis.call("decr", dailyRouteKey);
-return 0;
-`)
-
 func SubmitTeam(context *gin.Context) {
 	// 获取 jwt 数据
 	jwtToken := context.GetHeader("Authorization")[7:]
@@ -54,28 +37,35 @@ func SubmitTeam(context *gin.Context) {
 		log.Println(result.Error)
 		utility.ResponseError(context, "系统异常，请重试")
 		return
-	} else if team.Num < 4 {
-		utility.ResponseError(context, "队伍人数不足四人")
+	}
+	if err := policyService.GetPolicy(team.Route).CheckSubmit(&team); err != nil {
+		utility.ResponseError(context, err.Error())
 		return
 	}
 
 	teamID := strconv.Itoa(int(team.ID))
-	dailyRoute := utility.GetCurrentDate()*10 + team.Route
-	dailyRouteKey := strconv.Itoa(int(dailyRoute))
-	// 运行Lua脚本
-	n, err := submit.Run(global.Rctx, global.Rdb, []string{teamID, dailyRouteKey}).Int64()
+	dailyRouteKey := quotaService.Key(utility.GetCurrentDate(), team.Route)
+	n, err := teamService.Submit(teamID, dailyRouteKey)
 	if err != nil {
 		log.Println(err)
 		utility.ResponseError(context, "系统异常，请重试")
 		return
 	}
 
-	if n == 1 {
+	if n == teamService.SubmitDuplicate {
 		utility.ResponseError(context, "队伍已提交")
 		return
-	} else if n == 2 {
-		utility.ResponseError(context, "队伍数量已经到达上限，无法提交")
+	} else if n == teamService.SubmitFull {
+		// 名额已满时加入候补队列，有名额时自动提交
+		position, err := teamService.JoinWaitlist(teamID, dailyRouteKey)
+		if err != nil {
+			log.Println(err)
+			utility.ResponseError(context, "队伍数量已经到达上限，无法提交")
+			return
+		}
+		utility.ResponseError(context, "队伍数量已经到达上限，已加入候补第"+strconv.Itoa(int(position))+"位，有名额时将自动提交")
 		return
 	}
+	teamService.LeaveWaitlist(teamID)
 	utility.ResponseSuccess(context, nil)
 }
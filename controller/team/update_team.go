@@ -4,6 +4,8 @@ import (
 	"strconv"
 	"walk-server/global"
 	"walk-server/model"
+	"walk-server/service/posterService"
+	"walk-server/service/routeService"
 	"walk-server/utility"
 
 	"github.com/gin-gonic/gin"
@@ -13,9 +15,10 @@ import (
 type UpdateTeamData struct {
 	Name       string `json:"name" binding:"required"`
 	Route      uint8  `json:"route" binding:"required"`
-	Password   string `json:"password" binding:"required"`
+	Password   string `json:"password"`
 	Slogan     string `json:"slogan" binding:"required"`
 	AllowMatch *bool  `json:"allow_match" binding:"required"`
+	InviteOnly bool   `json:"invite_only"` // 为 true 时只能通过邀请加入，不需要密码
 }
 
 func UpdateTeam(context *gin.Context) {
@@ -42,6 +45,14 @@ func UpdateTeam(context *gin.Context) {
 		utility.ResponseError(context, "参数错误")
 		return
 	}
+	if !routeService.Exists(updateTeamData.Route) {
+		utility.ResponseError(context, "路线不存在")
+		return
+	}
+	if !updateTeamData.InviteOnly && updateTeamData.Password == "" {
+		utility.ResponseError(context, "请设置队伍密码")
+		return
+	}
 
 	// 更新团队信息
 	var team model.Team
@@ -55,8 +66,10 @@ func UpdateTeam(context *gin.Context) {
 	team.Name = updateTeamData.Name
 	team.Route = updateTeamData.Route
 	team.Password = updateTeamData.Password
+	team.InviteOnly = updateTeamData.InviteOnly
 	team.AllowMatch = *updateTeamData.AllowMatch
 	team.Slogan = updateTeamData.Slogan
 	global.DB.Save(&team)
+	posterService.Invalidate(team.ID)
 	utility.ResponseSuccess(context, nil)
 }
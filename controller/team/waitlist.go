@@ -0,0 +1,60 @@
+package team
+
+import (
+	"strconv"
+	"walk-server/model"
+	"walk-server/service/quotaService"
+	"walk-server/service/teamService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetWaitlist 获取队伍的候补状态
+func GetWaitlist(context *gin.Context) {
+	// 获取 jwt 数据
+	jwtToken := context.GetHeader("Authorization")[7:]
+	jwtData, _ := utility.ParseToken(jwtToken)
+
+	// 查找用户
+	person, _ := model.GetPerson(jwtData.OpenID)
+	if person.Status == 0 {
+		utility.ResponseError(context, "请先加入队伍")
+		return
+	}
+
+	dailyRouteKey, position, ok := teamService.GetWaitlistPosition(strconv.Itoa(person.TeamId))
+	if !ok {
+		utility.ResponseSuccess(context, gin.H{
+			"waiting": false,
+		})
+		return
+	}
+	day, route, _ := quotaService.ParseKey(dailyRouteKey)
+	utility.ResponseSuccess(context, gin.H{
+		"waiting":  true,
+		"day":      day,
+		"route":    route,
+		"position": position,
+	})
+}
+
+// CancelWaitlist 队长取消候补
+func CancelWaitlist(context *gin.Context) {
+	// 获取 jwt 数据
+	jwtToken := context.GetHeader("Authorization")[7:]
+	jwtData, _ := utility.ParseToken(jwtToken)
+
+	// 查找用户
+	person, _ := model.GetPerson(jwtData.OpenID)
+	if person.Status == 0 {
+		utility.ResponseError(context, "请先加入队伍")
+		return
+	} else if person.Status == 1 {
+		utility.ResponseError(context, "没有修改的权限")
+		return
+	}
+
+	teamService.LeaveWaitlist(strconv.Itoa(person.TeamId))
+	utility.ResponseSuccess(context, nil)
+}
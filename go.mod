@@ -15,6 +15,8 @@ require (
 	github.com/tidwall/gjson v1.18.0
 	github.com/xuri/excelize/v2 v2.9.0
 	github.com/zjutjh/WeJH-SDK v0.2.4
+	golang.org/x/crypto v0.33.0
+	golang.org/x/image v0.18.0
 	gorm.io/driver/mysql v1.5.7
 	gorm.io/gorm v1.25.12
 )
@@ -66,7 +68,6 @@ require (
 	github.com/xuri/nfp v0.0.0-20250226145837-86d5fc24b2ba // indirect
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/arch v0.14.0 // indirect
-	golang.org/x/crypto v0.33.0 // indirect
 	golang.org/x/net v0.35.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
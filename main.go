@@ -1,8 +1,14 @@
 package main
 
 import (
+	"flag"
+	"log"
 	"walk-server/global"
 	"walk-server/router"
+	"walk-server/service/adminService"
+	"walk-server/service/broadcastService"
+	"walk-server/service/teamService"
+	"walk-server/service/userService"
 	"walk-server/utility"
 	"walk-server/utility/initial"
 	"walk-server/utility/initial/wechat"
@@ -11,8 +17,25 @@ import (
 )
 
 func main() {
+	migrateEncrypt := flag.Bool("migrate-encrypt", false, "加密已有的身份证号和电话后退出")
+	flag.Parse()
+
 	initial.ConfigInit() // 读取配置
 	initial.DBInit()     // 初始化数据库
+
+	// 迁移已有数据，执行完后退出
+	if *migrateEncrypt {
+		migrated, failed, err := userService.EncryptExisting()
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("迁移完成，加密 %d 人，失败 %d 人\n", migrated, failed)
+		return
+	}
+
+	initial.RouteInit()  // 加载路线目录
+	initial.PosterInit() // 加载海报字体
+	initial.AdminInit()  // 迁移管理员密码、设置超级管理员
 	initial.RedisInit()  // 初始化Redis
 	initial.LimitInit()  // 初始化令牌桶
 	wechat.WeChatInit()
@@ -22,9 +45,16 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	utility.OnNotifyDone(broadcastService.NotifyKind, broadcastService.OnDelivered)
+	go utility.RunNotifyWorkers()          // 发送通知
+	go adminService.RunDetailFeed()        // 看板实时推送
+	go teamService.RunReconcile()          // 定时对账
+	go teamService.RunExpireJoinRequests() // 清理过期的加入申请
+	go teamService.RunDetectLostTeams()    // 自动标记失联队伍
+	go utility.RunCleanFiles()             // 清理过期的导出文件和海报
+
 	// 初始化路由
 	r := initial.RouterInit()
-	r.Static("/file", "./file")
 	//r.Use(middleware.Time())
 	router.MountRoutes(r)
 
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"walk-server/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+)
+
+// 参数名包含这些字段时记录为 ***
+var sensitiveKeys = []string{"password", "token", "secret", "jwt"}
+
+const maxAuditRequestLen = 4096
+
+// SetAuditTeam 记录本次请求操作的队伍
+func SetAuditTeam(context *gin.Context, teamID uint) {
+	context.Set("audit_team", teamID)
+}
+
+// SetAuditPerson 记录本次请求操作的用户
+func SetAuditPerson(context *gin.Context, openID string) {
+	context.Set("audit_person", openID)
+}
+
+// Audit 记录管理端接口的调用：管理员、接口、操作对象、返回结果和耗时
+// 管理员信息由 CheckAdmin 或登录接口写入上下文，操作对象优先使用接口设置的值，否则从参数中的 team_id 获取
+func Audit(context *gin.Context) {
+	start := time.Now()
+
+	// 只读取并记录 JSON 请求体，上传文件、表单等其他请求体只记录类型和长度，避免缓存整个文件或记录未脱敏的内容
+	requestData := make(map[string]interface{})
+	if context.ContentType() == gin.MIMEJSON {
+		rawData, err := context.GetRawData()
+		if err == nil && len(rawData) > 0 {
+			// 重置请求体，以便后续处理中读取请求体
+			context.Request.Body = io.NopCloser(bytes.NewBuffer(rawData))
+			if err := json.Unmarshal(rawData, &requestData); err != nil || requestData == nil {
+				requestData = map[string]interface{}{"body_length": len(rawData)}
+			}
+		}
+	} else if context.Request.ContentLength != 0 {
+		requestData["content_type"] = context.ContentType()
+		requestData["content_length"] = context.Request.ContentLength
+	}
+	for key, values := range context.Request.URL.Query() {
+		if _, exists := requestData[key]; !exists && len(values) > 0 {
+			requestData[key] = values[0] // 只取第一个值
+		}
+	}
+
+	context.Next()
+
+	auditLog := model.AuditLog{
+		Method:   context.Request.Method,
+		Endpoint: context.FullPath(),
+		IP:       context.ClientIP(),
+		Code:     context.GetInt("response_code"),
+		Message:  context.GetString("response_msg"),
+		Latency:  time.Since(start).Milliseconds(),
+		Time:     start,
+	}
+	if auditLog.Endpoint == "" {
+		auditLog.Endpoint = context.Request.URL.Path
+	}
+	if value, exists := context.Get("admin"); exists {
+		admin := value.(*model.Admin)
+		auditLog.AdminID = admin.ID
+		auditLog.AdminName = admin.Name
+		auditLog.Role = admin.Role
+		auditLog.Route = admin.Route
+		auditLog.Point = admin.Point
+	}
+	if value, exists := context.Get("audit_team"); exists {
+		auditLog.TeamID = value.(uint)
+	} else {
+		auditLog.TeamID = parseTeamID(requestData["team_id"])
+	}
+	auditLog.PersonID = context.GetString("audit_person")
+
+	data, _ := json.Marshal(redact(requestData))
+	auditLog.Request = truncate(string(data), maxAuditRequestLen)
+	auditLog.Message = truncate(auditLog.Message, 255)
+
+	// 异步写入，不影响接口响应
+	go func() {
+		if err := model.InsertAuditLog(&auditLog); err != nil {
+			log.Println(err)
+		}
+	}()
+}
+
+// redact 将敏感参数替换为 ***
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isSensitiveKey(key) {
+				v[key] = "***"
+			} else {
+				v[key] = redact(item)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTeamID(value interface{}) uint {
+	switch v := value.(type) {
+	case float64:
+		return uint(v)
+	case string:
+		id, _ := strconv.Atoi(v)
+		return uint(id)
+	default:
+		return 0
+	}
+}
+
+// truncate 按字符截断，避免截断半个汉字
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
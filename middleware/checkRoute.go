@@ -1,12 +1,11 @@
 package middleware
 
-import "walk-server/model"
+import (
+	"walk-server/model"
+	"walk-server/service/routeService"
+)
 
-// CheckRoute 检查管理员权限
+// CheckRoute 检查管理员权限，同一片区的路线可以互相扫码
 func CheckRoute(admin *model.Admin, team *model.Team) bool {
-	return team.Route == admin.Route ||
-		(team.Route == 4 && admin.Route == 5) ||
-		(team.Route == 5 && admin.Route == 4) ||
-		(team.Route == 2 && admin.Route == 3) ||
-		(team.Route == 3 && admin.Route == 2)
+	return routeService.IsSameArea(team.Route, admin.Route)
 }
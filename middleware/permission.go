@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"walk-server/model"
+	"walk-server/service/adminService"
+	"walk-server/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission 检查管理员是否拥有接口需要的权限，需要放在 CheckAdmin 之后
+// 需要修改密码的管理员在修改前无法使用任何需要权限的接口
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		value, exists := context.Get("admin")
+		if !exists {
+			utility.ResponseError(context, "未登陆")
+			context.Abort()
+			return
+		}
+
+		admin := value.(*model.Admin)
+		if admin.MustChangePassword {
+			utility.ResponseError(context, "请先修改密码")
+			context.Abort()
+			return
+		}
+
+		if !adminService.HasPermission(admin.Role, permission) {
+			utility.ResponseError(context, "没有权限")
+			context.Abort()
+			return
+		}
+
+		context.Next()
+	}
+}
@@ -1,11 +1,20 @@
 package model
 
+// 管理员角色
+const (
+	RoleSuper     uint8 = 1 // 超级管理员
+	RoleRouteLead uint8 = 2 // 路线负责人，可以管理所在片区的队伍
+	RoleStaff     uint8 = 3 // 点位工作人员，只能扫码
+)
+
 type Admin struct {
-	ID           uint   `json:"admin_id"`
-	WechatOpenID string `json:"-"`
-	Name         string `json:"name"`
-	Account      string `json:"account"`
-	Password     string `json:"-"`
-	Point        int8   `json:"point"`
-	Route        uint8  `json:"route"` // 1 是朝晖路线，2 屏峰半程，3 屏峰全程，4 莫干山半程，5 莫干山全程
+	ID                 uint   `json:"admin_id"`
+	WechatOpenID       string `json:"-"`
+	Name               string `json:"name"`
+	Account            string `json:"account"`
+	Password           string `json:"-"`
+	Point              int8   `json:"point"`
+	Route              uint8  `json:"route"` // 1 是朝晖路线，2 屏峰半程，3 屏峰全程，4 莫干山半程，5 莫干山全程
+	Role               uint8  `gorm:"not null;default:3;comment:角色(1超级管理员,2路线负责人,3点位工作人员)" json:"role"`
+	MustChangePassword bool   `gorm:"not null;default:false;comment:是否需要修改密码" json:"must_change_password"`
 }
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"time"
+	"walk-server/global"
+)
+
+// AuditLog 管理端接口的调用记录
+type AuditLog struct {
+	ID        uint      `json:"id"`
+	AdminID   uint      `gorm:"index;not null;comment:管理员ID(登录失败时为0)" json:"admin_id"`
+	AdminName string    `gorm:"size:128;comment:管理员姓名" json:"admin_name"`
+	Role      uint8     `gorm:"not null;comment:管理员角色" json:"role"`
+	Route     uint8     `gorm:"index;not null;comment:管理员路线" json:"route"`
+	Point     int8      `gorm:"not null;comment:管理员点位" json:"point"`
+	Method    string    `gorm:"size:8;not null;comment:请求方法" json:"method"`
+	Endpoint  string    `gorm:"size:128;index;not null;comment:接口路径" json:"endpoint"`
+	TeamID    uint      `gorm:"index;comment:操作的队伍ID" json:"team_id"`
+	PersonID  string    `gorm:"size:64;index;comment:操作的用户OpenID" json:"person_id"`
+	Request   string    `gorm:"type:text;comment:请求参数(已脱敏)" json:"request"`
+	Code      int       `gorm:"not null;comment:返回码" json:"code"`
+	Message   string    `gorm:"size:255;comment:返回信息" json:"message"`
+	Latency   int64     `gorm:"not null;comment:处理耗时(毫秒)" json:"latency"`
+	IP        string    `gorm:"size:64;comment:请求IP" json:"ip"`
+	Time      time.Time `gorm:"index;comment:请求时间" json:"time"`
+}
+
+// AuditFilter 查询调用记录的条件，零值表示不限
+type AuditFilter struct {
+	AdminID uint
+	Route   uint8
+	Point   *int8
+	TeamID  uint
+	Start   time.Time
+	End     time.Time
+}
+
+func InsertAuditLog(log *AuditLog) error {
+	return global.DB.Create(log).Error
+}
+
+// GetAuditLogs 按时间倒序分页获取调用记录，limit 为 0 时不分页，同时返回符合条件的总数
+func GetAuditLogs(filter AuditFilter, offset int, limit int) ([]AuditLog, int64, error) {
+	query := global.DB.Model(&AuditLog{})
+	if filter.AdminID != 0 {
+		query = query.Where("admin_id = ?", filter.AdminID)
+	}
+	if filter.Route != 0 {
+		query = query.Where("route = ?", filter.Route)
+	}
+	if filter.Point != nil {
+		query = query.Where("point = ?", *filter.Point)
+	}
+	if filter.TeamID != 0 {
+		query = query.Where("team_id = ?", filter.TeamID)
+	}
+	if !filter.Start.IsZero() {
+		query = query.Where("time >= ?", filter.Start)
+	}
+	if !filter.End.IsZero() {
+		query = query.Where("time < ?", filter.End)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []AuditLog
+	query = query.Order("id DESC")
+	if limit > 0 {
+		query = query.Offset(offset).Limit(limit)
+	}
+	err := query.Find(&logs).Error
+	return logs, total, err
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"time"
+	"walk-server/global"
+
+	"gorm.io/gorm"
+)
+
+// BroadcastFilter 广播的接收范围，各条件之间为且的关系，零值表示不限
+type BroadcastFilter struct {
+	Routes       []uint8 `json:"routes"`        // 路线
+	PointFrom    *int8   `json:"point_from"`    // 队伍最后扫码的点位不小于该点位
+	PointTo      *int8   `json:"point_to"`      // 队伍最后扫码的点位小于该点位
+	Statuses     []uint8 `json:"statuses"`      // 队伍状态
+	IsLost       *bool   `json:"is_lost"`       // 是否失联
+	TeamIDs      []uint  `json:"team_ids"`      // 指定队伍
+	WalkStatuses []uint8 `json:"walk_statuses"` // 成员行进状态，例如只发给仍在行进的成员
+}
+
+// Broadcast 管理员发送的广播
+type Broadcast struct {
+	ID        uint            `json:"id"`
+	AdminID   uint            `gorm:"index;not null;comment:发送的管理员ID" json:"admin_id"`
+	AdminName string          `gorm:"size:128;comment:发送的管理员姓名" json:"admin_name"`
+	Message   string          `gorm:"type:text;not null;comment:广播内容" json:"message"`
+	Channel   string          `gorm:"size:32;comment:推送渠道" json:"channel"`
+	Filter    BroadcastFilter `gorm:"type:text;serializer:json;comment:接收范围" json:"filter"`
+	Total     int             `gorm:"not null;default:0;comment:接收人数" json:"total"`
+	Sent      int             `gorm:"not null;default:0;comment:推送成功人数" json:"sent"`
+	Failed    int             `gorm:"not null;default:0;comment:推送失败人数" json:"failed"`
+	Finished  bool            `gorm:"not null;default:false;comment:是否发送完毕" json:"finished"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+const (
+	ReceiptPending uint8 = iota + 1 // 等待发送
+	ReceiptSent                     // 发送成功
+	ReceiptFailed                   // 发送失败
+)
+
+// BroadcastReceipt 广播发给每个接收人的结果，站内消息总是写入，推送可能失败
+type BroadcastReceipt struct {
+	ID          uint       `json:"id"`
+	BroadcastID uint       `gorm:"index;not null;comment:广播ID" json:"broadcast_id"`
+	TeamID      uint       `gorm:"index;not null;comment:队伍ID" json:"team_id"`
+	OpenId      string     `gorm:"size:64;not null;comment:接收人OpenID" json:"open_id"`
+	Name        string     `gorm:"size:128;comment:接收人姓名" json:"name"`
+	MessageID   uint       `gorm:"not null;default:0;comment:站内消息ID" json:"message_id"`
+	Status      uint8      `gorm:"not null;default:1;comment:推送状态(1等待发送,2成功,3失败)" json:"status"`
+	Error       string     `gorm:"size:255;comment:失败原因" json:"error"`
+	SentAt      *time.Time `gorm:"comment:发送时间" json:"sent_at"`
+}
+
+// CreateBroadcast 在同一事务中写入广播、站内消息和发送记录
+func CreateBroadcast(broadcast *Broadcast, receipts []BroadcastReceipt) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(broadcast).Error; err != nil {
+			return err
+		}
+		if len(receipts) == 0 {
+			return nil
+		}
+
+		messages := make([]Message, 0, len(receipts))
+		for _, receipt := range receipts {
+			messages = append(messages, Message{
+				SenderOpenId:   "",
+				ReceiverOpenId: receipt.OpenId,
+				Message:        broadcast.Message,
+				Category:       MessageBroadcast,
+			})
+		}
+		if err := tx.CreateInBatches(&messages, 500).Error; err != nil {
+			return err
+		}
+
+		for i := range receipts {
+			receipts[i].BroadcastID = broadcast.ID
+			receipts[i].MessageID = messages[i].ID
+			receipts[i].Status = ReceiptPending
+		}
+		return tx.CreateInBatches(&receipts, 500).Error
+	})
+}
+
+// UpdateReceipt 记录接收人的推送结果，累加广播的成功和失败人数，全部接收人都有结果时广播发送完毕
+func UpdateReceipt(id uint, status uint8, errMsg string) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		var receipt BroadcastReceipt
+		if err := tx.Take(&receipt, id).Error; err != nil {
+			return err
+		}
+		if receipt.Status != ReceiptPending {
+			return nil
+		}
+
+		now := time.Now()
+		if err := tx.Model(&receipt).Updates(map[string]any{
+			"status":  status,
+			"error":   errMsg,
+			"sent_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		column := "sent"
+		if status == ReceiptFailed {
+			column = "failed"
+		}
+		if err := tx.Model(&Broadcast{}).Where("id = ?", receipt.BroadcastID).Update(column, gorm.Expr(column+" + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Broadcast{}).Where("id = ?", receipt.BroadcastID).
+			Update("finished", gorm.Expr("sent + failed >= total")).Error
+	})
+}
+
+func GetBroadcast(id uint) (*Broadcast, error) {
+	var broadcast Broadcast
+	if err := global.DB.Take(&broadcast, id).Error; err != nil {
+		return nil, err
+	}
+	return &broadcast, nil
+}
+
+// GetBroadcasts 按时间倒序分页获取广播，adminID 为 0 时获取全部
+func GetBroadcasts(adminID uint, offset int, limit int) ([]Broadcast, int64, error) {
+	query := global.DB.Model(&Broadcast{})
+	if adminID != 0 {
+		query = query.Where("admin_id = ?", adminID)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var broadcasts []Broadcast
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&broadcasts).Error
+	return broadcasts, total, err
+}
+
+// GetReceipts 获取广播的发送记录，status 为 0 时获取全部
+func GetReceipts(broadcastID uint, status uint8) ([]BroadcastReceipt, error) {
+	query := global.DB.Where("broadcast_id = ?", broadcastID)
+	if status != 0 {
+		query = query.Where("status = ?", status)
+	}
+	var receipts []BroadcastReceipt
+	err := query.Order("id").Find(&receipts).Error
+	return receipts, err
+}
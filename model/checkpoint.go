@@ -0,0 +1,79 @@
+package model
+
+import (
+	"time"
+	"walk-server/global"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MemberWalkStatus 打卡时成员行进状态的变化
+type MemberWalkStatus struct {
+	OpenID     string `json:"open_id"`
+	PrevStatus uint8  `json:"prev_status"`
+	Status     uint8  `json:"status"`
+}
+
+// Checkpoint 队伍每次扫码打卡的记录
+type Checkpoint struct {
+	ID             uint               `json:"id"`
+	TeamID         uint               `gorm:"index;not null;comment:队伍ID" json:"team_id"`
+	AdminID        uint               `gorm:"not null;comment:扫码管理员ID" json:"admin_id"`
+	Route          uint8              `gorm:"not null;comment:队伍路线" json:"route"`
+	PrevPoint      int8               `gorm:"not null;comment:扫码前点位" json:"prev_point"`
+	Point          int8               `gorm:"not null;comment:扫码后点位" json:"point"`
+	PrevStatus     uint8              `gorm:"not null;comment:扫码前队伍状态" json:"prev_status"`
+	Status         uint8              `gorm:"not null;comment:扫码后队伍状态" json:"status"`
+	Num            uint               `gorm:"not null;comment:仍在行进的人数" json:"num"`
+	Time           time.Time          `gorm:"index;comment:扫码时间" json:"time"`
+	PrevTime       time.Time          `gorm:"comment:扫码前队伍状态更新时间" json:"prev_time"`
+	PrevLost       bool               `gorm:"not null;default:false;comment:扫码前是否失联" json:"prev_lost"`
+	PrevLostReason string             `gorm:"size:255;comment:扫码前失联原因" json:"prev_lost_reason"`
+	PrevCode       string             `gorm:"size:128;comment:扫码前绑定码" json:"-"`
+	PrevStartNum   uint               `gorm:"not null;default:0;comment:扫码前开始时人数" json:"prev_start_num"`
+	Members        []MemberWalkStatus `gorm:"type:text;serializer:json;comment:成员状态变化" json:"members"`
+	Reverted       bool               `gorm:"not null;default:false;comment:是否已撤销" json:"reverted"`
+	RevertedBy     uint               `gorm:"not null;default:0;comment:撤销的管理员ID" json:"reverted_by"`
+	RevertReason   string             `gorm:"size:255;comment:撤销原因" json:"revert_reason"`
+	RevertedAt     *time.Time         `gorm:"comment:撤销时间" json:"reverted_at"`
+}
+
+func InsertCheckpoint(checkpoint *Checkpoint) error {
+	return global.DB.Create(checkpoint).Error
+}
+
+// GetCheckpoints 按时间顺序获取队伍的打卡记录，包含已撤销的记录
+func GetCheckpoints(teamID uint) ([]Checkpoint, error) {
+	var checkpoints []Checkpoint
+	result := global.DB.Where("team_id = ?", teamID).Order("time, id").Find(&checkpoints)
+	return checkpoints, result.Error
+}
+
+// TxGetLastCheckpoint 在事务中锁定并获取队伍最后一条未撤销的打卡记录
+func TxGetLastCheckpoint(tx *gorm.DB, teamID uint) (*Checkpoint, error) {
+	var checkpoint Checkpoint
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("team_id = ? AND reverted = ?", teamID, false).
+		Order("id DESC").
+		Take(&checkpoint).Error
+	if err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// TxRevertCheckpoint 在事务中将打卡记录标记为已撤销
+func TxRevertCheckpoint(tx *gorm.DB, checkpoint *Checkpoint, adminID uint, reason string) error {
+	now := time.Now()
+	checkpoint.Reverted = true
+	checkpoint.RevertedBy = adminID
+	checkpoint.RevertReason = reason
+	checkpoint.RevertedAt = &now
+	return tx.Model(checkpoint).Updates(map[string]any{
+		"reverted":      true,
+		"reverted_by":   adminID,
+		"revert_reason": reason,
+		"reverted_at":   now,
+	}).Error
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+	"walk-server/global"
+
+	"gorm.io/gorm"
+)
+
+// Invitation 队长生成的队伍邀请
+type Invitation struct {
+	ID        uint      `json:"id"`
+	TeamID    uint      `gorm:"index;not null;comment:队伍ID" json:"team_id"`
+	Creator   string    `gorm:"size:64;not null;comment:创建者OpenID" json:"-"`
+	MaxUses   uint      `gorm:"not null;comment:最多可使用次数" json:"max_uses"`
+	Used      uint      `gorm:"not null;default:0;comment:已使用次数" json:"used"`
+	Revoked   bool      `gorm:"not null;default:false;comment:是否已撤销" json:"revoked"`
+	ExpiresAt time.Time `gorm:"comment:过期时间" json:"expires_at"`
+	CreatedAt time.Time `gorm:"comment:创建时间" json:"created_at"`
+}
+
+// Valid 邀请是否仍然可以使用
+func (invitation *Invitation) Valid() bool {
+	return !invitation.Revoked && invitation.Used < invitation.MaxUses && time.Now().Before(invitation.ExpiresAt)
+}
+
+func InsertInvitation(invitation *Invitation) error {
+	return global.DB.Create(invitation).Error
+}
+
+func GetInvitation(id uint) (*Invitation, error) {
+	invitation := new(Invitation)
+	result := global.DB.Where("id = ?", id).Take(invitation)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return invitation, nil
+}
+
+// GetInvitations 按创建时间倒序获取队伍的邀请
+func GetInvitations(teamID uint) ([]Invitation, error) {
+	var invitations []Invitation
+	result := global.DB.Where("team_id = ?", teamID).Order("id DESC").Find(&invitations)
+	return invitations, result.Error
+}
+
+// RevokeInvitation 撤销队伍的邀请，返回是否找到该邀请
+func RevokeInvitation(teamID uint, id uint) (bool, error) {
+	result := global.DB.Model(&Invitation{}).Where("id = ? AND team_id = ?", id, teamID).Update("revoked", true)
+	return result.RowsAffected > 0, result.Error
+}
+
+// TxUseInvitation 在事务中消耗一次邀请，邀请已失效时返回 false
+func TxUseInvitation(tx *gorm.DB, id uint) (bool, error) {
+	result := tx.Model(&Invitation{}).
+		Where("id = ? AND revoked = ? AND used < max_uses AND expires_at > ?", id, false, time.Now()).
+		Update("used", gorm.Expr("used + 1"))
+	return result.RowsAffected > 0, result.Error
+}
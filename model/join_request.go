@@ -0,0 +1,83 @@
+package model
+
+import (
+	"time"
+	"walk-server/global"
+
+	"gorm.io/gorm"
+)
+
+// 加入申请的状态
+const (
+	JoinRequestPending   uint8 = 1 // 待处理
+	JoinRequestApproved  uint8 = 2 // 已通过
+	JoinRequestRejected  uint8 = 3 // 已拒绝
+	JoinRequestExpired   uint8 = 4 // 已过期
+	JoinRequestCancelled uint8 = 5 // 已取消
+)
+
+// 加入申请的来源
+const (
+	JoinSourcePassword uint8 = 1 // 通过密码申请
+	JoinSourceRandom   uint8 = 2 // 通过随机组队申请
+)
+
+// JoinRequest 用户加入队伍的申请，由队长审批
+type JoinRequest struct {
+	ID        uint      `json:"id"`
+	TeamID    uint      `gorm:"index;not null;comment:队伍ID" json:"team_id"`
+	OpenId    string    `gorm:"size:64;index;not null;comment:申请人OpenID" json:"-"`
+	Source    uint8     `gorm:"not null;comment:来源(1密码,2随机组队)" json:"source"`
+	Status    uint8     `gorm:"index;not null;default:1;comment:状态(1待处理,2已通过,3已拒绝,4已过期,5已取消)" json:"status"`
+	CreatedAt time.Time `gorm:"comment:申请时间" json:"created_at"`
+	HandledAt time.Time `gorm:"comment:处理时间" json:"handled_at"`
+}
+
+func InsertJoinRequest(request *JoinRequest) error {
+	return global.DB.Create(request).Error
+}
+
+func GetJoinRequest(id uint) (*JoinRequest, error) {
+	request := new(JoinRequest)
+	result := global.DB.Where("id = ?", id).Take(request)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return request, nil
+}
+
+// GetPendingJoinRequest 获取用户待处理的申请，没有时返回 nil
+func GetPendingJoinRequest(openID string) *JoinRequest {
+	request := new(JoinRequest)
+	result := global.DB.Where("open_id = ? AND status = ?", openID, JoinRequestPending).Take(request)
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	return request
+}
+
+// GetPendingJoinRequests 按申请时间获取队伍待处理的申请
+func GetPendingJoinRequests(teamID uint) ([]JoinRequest, error) {
+	var requests []JoinRequest
+	result := global.DB.Where("team_id = ? AND status = ?", teamID, JoinRequestPending).Order("id").Find(&requests)
+	return requests, result.Error
+}
+
+// GetJoinRequestsBefore 获取在 t 之前提交且仍未处理的申请
+func GetJoinRequestsBefore(t time.Time) ([]JoinRequest, error) {
+	var requests []JoinRequest
+	result := global.DB.Where("status = ? AND created_at < ?", JoinRequestPending, t).Find(&requests)
+	return requests, result.Error
+}
+
+// HandleJoinRequest 将待处理的申请改为 status，申请已不是待处理状态时返回 false
+func HandleJoinRequest(id uint, status uint8) (bool, error) {
+	return TxHandleJoinRequest(global.DB, id, status)
+}
+
+// TxHandleJoinRequest 事务中处理申请
+func TxHandleJoinRequest(tx *gorm.DB, id uint, status uint8) (bool, error) {
+	result := tx.Model(&JoinRequest{}).Where("id = ? AND status = ?", id, JoinRequestPending).
+		Updates(map[string]interface{}{"status": status, "handled_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
@@ -1,24 +1,42 @@
 package model
 
 import (
-	"errors"
 	"time"
 	"walk-server/global"
 )
 
+// 消息分类
+const (
+	MessageSystem    uint8 = iota + 1 // 系统消息
+	MessageTeam                       // 队伍变动
+	MessageBroadcast                  // 管理员广播
+)
+
 type Message struct {
 	ID             uint
 	SenderOpenId   string // 如果发送者 open ID 为空, 相当于系统消息
 	ReceiverOpenId string `gorm:"index"`
 	Message        string
+	Category       uint8      `gorm:"not null;default:1;comment:分类(1系统,2队伍变动,3管理员广播)"`
+	Read           bool       `gorm:"not null;default:false;comment:是否已读"`
+	ReadAt         *time.Time `gorm:"comment:阅读时间"`
+	Archived       bool       `gorm:"not null;default:false;comment:是否已归档(用户删除的消息)"`
 	CreatedAt      time.Time
 }
 
-func InsertMessage(message string, senderOpenID string, receiverOpenID string) {
+// MessageFilter 查询消息的条件，零值表示不限
+type MessageFilter struct {
+	Category   uint8
+	UnreadOnly bool
+	Archived   bool // 为 true 时只查询已归档的消息，否则只查询未归档的消息
+}
+
+func InsertMessage(message string, category uint8, senderOpenID string, receiverOpenID string) {
 	global.DB.Create(&Message{
 		SenderOpenId:   senderOpenID,
 		ReceiverOpenId: receiverOpenID,
 		Message:        message,
+		Category:       category,
 	})
 }
 
@@ -26,16 +44,71 @@ func InsertMessages(messages *[]Message) {
 	global.DB.Create(messages)
 }
 
-func GetMessages(receiverOpenID string) ([]Message, error) {
+// GetMessages 按时间倒序获取消息，cursor 为上一页最后一条消息的 ID，为 0 时从最新的消息开始
+func GetMessages(receiverOpenID string, filter MessageFilter, cursor uint, limit int) ([]Message, error) {
+	query := global.DB.Where("receiver_open_id = ? AND archived = ?", receiverOpenID, filter.Archived)
+	if filter.Category != 0 {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.UnreadOnly {
+		query = query.Where("`read` = ?", false)
+	}
+	if cursor != 0 {
+		query = query.Where("id < ?", cursor)
+	}
+
 	var messages []Message
-	result := global.DB.Where("receiver_open_id = ?", receiverOpenID).Find(&messages)
-	if result.RowsAffected == 0 {
-		return nil, errors.New("no result")
-	} else {
-		return messages, nil
+	err := query.Order("id DESC").Limit(limit).Find(&messages).Error
+	return messages, err
+}
+
+// CountUnread 获取各分类未读且未归档的消息数量
+func CountUnread(receiverOpenID string) (map[uint8]int64, error) {
+	var rows []struct {
+		Category uint8
+		Count    int64
 	}
+	err := global.DB.Model(&Message{}).
+		Select("category, COUNT(*) AS count").
+		Where("receiver_open_id = ? AND `read` = ? AND archived = ?", receiverOpenID, false, false).
+		Group("category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint8]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Category] = row.Count
+	}
+	return counts, nil
+}
+
+// MarkRead 将消息标记为已读，ids 为空时标记该分类（category 为 0 时为全部分类）的全部消息，返回标记的数量
+func MarkRead(receiverOpenID string, ids []uint, category uint8) (int64, error) {
+	query := global.DB.Model(&Message{}).Where("receiver_open_id = ? AND `read` = ?", receiverOpenID, false)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	if category != 0 {
+		query = query.Where("category = ?", category)
+	}
+	result := query.Updates(map[string]any{"read": true, "read_at": time.Now()})
+	return result.RowsAffected, result.Error
 }
 
-func DeleteMessage(id uint) {
-	global.DB.Delete(&Message{}, id)
+// ArchiveMessage 归档消息，归档时同时标记为已读，返回是否找到接收者的消息
+func ArchiveMessage(receiverOpenID string, id uint) (bool, error) {
+	var message Message
+	result := global.DB.Where("id = ? AND receiver_open_id = ?", id, receiverOpenID).Limit(1).Find(&message)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	updates := map[string]any{"archived": true}
+	if !message.Read {
+		updates["read"] = true
+		updates["read_at"] = time.Now()
+	}
+	return true, global.DB.Model(&message).Updates(updates).Error
 }
@@ -5,8 +5,10 @@ import (
 	"github.com/goccy/go-json"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"strings"
 	"time"
 	"walk-server/global"
+	"walk-server/utility/fieldcrypt"
 )
 
 type Person struct {
@@ -15,25 +17,76 @@ type Person struct {
 	Gender     int8   `gorm:"not null;comment:性别(1男,2女)"`
 	StuId      string `gorm:"size:32;unique;comment:学号"`
 	Campus     uint8  `gorm:"not null;comment:校区(1朝晖,2屏峰,3莫干山)"`
-	Identity   string `gorm:"size:18;unique;not null;comment:身份证号"`
+	Identity   string `gorm:"size:128;not null;serializer:encrypt;comment:身份证号(加密)"`
 	Status     uint8  `gorm:"not null;default:0;comment:队伍状态(0未加入,1队员,2队长)"`
 	Qq         string `gorm:"size:20;comment:QQ号"`
 	Wechat     string `gorm:"size:64;comment:微信号"`
 	College    string `gorm:"size:64;not null;comment:学院"`
-	Tel        string `gorm:"size:20;unique;not null;comment:联系电话"`
+	Tel        string `gorm:"size:128;not null;serializer:encrypt;comment:联系电话(加密)"`
 	CreatedOp  uint8  `gorm:"not null;default:3;comment:创建团队次数"`
 	JoinOp     uint8  `gorm:"not null;default:5;comment:加入团队次数"`
 	TeamId     int    `gorm:"index;default:-1;comment:所属团队ID"`
 	Type       uint8  `gorm:"not null;comment:人员类型(1学生,2教职工,3校友)"`
 	WalkStatus uint8  `gorm:"not null;default:1;comment:活动状态(1未开始,2进行中,3扫码成功,4放弃,5完成)"`
+
+	IdentityHash string `gorm:"size:64;uniqueIndex;comment:身份证号盲索引" json:"-"`
+	TelHash      string `gorm:"size:64;uniqueIndex;comment:联系电话盲索引" json:"-"`
+}
+
+// BeforeSave 写入前根据明文计算身份证号和电话的盲索引，用于唯一约束和查询
+func (p *Person) BeforeSave(tx *gorm.DB) error {
+	if p.Identity != "" {
+		p.IdentityHash = IdentityHash(p.Identity)
+	}
+	if p.Tel != "" {
+		p.TelHash = TelHash(p.Tel)
+	}
+	return nil
+}
+
+// NormalizeIdentity 统一身份证号的格式：去除首尾空格，末位的 x 转为大写
+// 写入、导入和登录查询都经过这里，保证同一个身份证号得到相同的盲索引
+func NormalizeIdentity(identity string) string {
+	return strings.ToUpper(strings.TrimSpace(identity))
+}
+
+// NormalizeTel 统一电话的格式：去除首尾空格
+func NormalizeTel(tel string) string {
+	return strings.TrimSpace(tel)
 }
 
+// IdentityHash 计算身份证号的盲索引，用于按身份证号查询
+func IdentityHash(identity string) string {
+	return fieldcrypt.BlindIndex("identity", NormalizeIdentity(identity))
+}
+
+// TelHash 计算电话的盲索引，用于按电话查询
+func TelHash(tel string) string {
+	return fieldcrypt.BlindIndex("tel", NormalizeTel(tel))
+}
+
+// MarshalBinary 写入 Redis 缓存，身份证号和电话与数据库一样加密保存
 func (p *Person) MarshalBinary() (data []byte, err error) {
-	return json.Marshal(p)
+	cached := *p
+	if cached.Identity, err = fieldcrypt.Encrypt(p.Identity); err != nil {
+		return nil, err
+	}
+	if cached.Tel, err = fieldcrypt.Encrypt(p.Tel); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&cached)
 }
 
-func (p *Person) UnmarshalBinary(data []byte) error {
-	return json.Unmarshal(data, p)
+// UnmarshalBinary 读取 Redis 缓存并解密身份证号和电话
+func (p *Person) UnmarshalBinary(data []byte) (err error) {
+	if err = json.Unmarshal(data, p); err != nil {
+		return err
+	}
+	if p.Identity, err = fieldcrypt.Decrypt(p.Identity); err != nil {
+		return err
+	}
+	p.Tel, err = fieldcrypt.Decrypt(p.Tel)
+	return err
 }
 
 // GetPerson 使用加密后的 open ID 获取 person 数据
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"os"
+	"testing"
+	"walk-server/global"
+)
+
+func TestMain(m *testing.M) {
+	global.Config.Set("server.fieldSecret", "test-field-secret")
+	os.Exit(m.Run())
+}
+
+// 导入、注册和登录查询输入的身份证号格式不同，盲索引必须一致
+func TestIdentityHashNormalizes(t *testing.T) {
+	want := IdentityHash("11010519491231002X")
+	for _, identity := range []string{"11010519491231002x", " 11010519491231002X", "11010519491231002x\t"} {
+		if got := IdentityHash(identity); got != want {
+			t.Errorf("IdentityHash(%q) differs from the normalized value", identity)
+		}
+	}
+	if got := TelHash(" 13800000000 "); got != TelHash("13800000000") {
+		t.Errorf("TelHash does not trim spaces: %q", got)
+	}
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+	"walk-server/global"
+)
+
+// QuotaLog 名额调整记录
+type QuotaLog struct {
+	ID            uint      `json:"id"`
+	AdminID       uint      `gorm:"index;not null;comment:操作的管理员ID" json:"admin_id"`
+	Day           uint8     `gorm:"not null;comment:报名第几天" json:"day"`
+	Route         uint8     `gorm:"not null;comment:路线" json:"route"`
+	PrevTotal     int64     `gorm:"not null;comment:调整前总名额" json:"prev_total"`
+	Total         int64     `gorm:"not null;comment:调整后总名额" json:"total"`
+	PrevRemaining int64     `gorm:"not null;comment:调整前剩余名额" json:"prev_remaining"`
+	Remaining     int64     `gorm:"not null;comment:调整后剩余名额" json:"remaining"`
+	Time          time.Time `gorm:"index;comment:调整时间" json:"time"`
+}
+
+func InsertQuotaLog(log *QuotaLog) error {
+	return global.DB.Create(log).Error
+}
+
+// GetQuotaLogs 按时间倒序获取名额调整记录
+func GetQuotaLogs() ([]QuotaLog, error) {
+	var logs []QuotaLog
+	result := global.DB.Order("id DESC").Find(&logs)
+	return logs, result.Error
+}
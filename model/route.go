@@ -0,0 +1,18 @@
+package model
+
+// Route 路线目录，点位按 Point 从 0（起点）开始依次排列，最后一个点位为终点
+type Route struct {
+	ID     uint8        `gorm:"primaryKey;autoIncrement:false;comment:路线ID" json:"id"`
+	Code   string       `gorm:"size:32;unique;not null;comment:路线标识(用于接口返回的键名)" json:"code"`
+	Name   string       `gorm:"size:64;not null;comment:路线名称" json:"name"`
+	Area   uint8        `gorm:"not null;default:0;comment:片区(同一片区的路线管理员可以互相扫码,0为不共享)" json:"area"`
+	Points []RoutePoint `gorm:"foreignKey:RouteID" json:"points"`
+}
+
+type RoutePoint struct {
+	ID       uint   `json:"-"`
+	RouteID  uint8  `gorm:"index;not null;comment:路线ID" json:"-"`
+	Point    int8   `gorm:"not null;comment:点位序号(0为起点)" json:"point"`
+	Name     string `gorm:"size:64;not null;comment:点位名称" json:"name"`
+	Duration uint16 `gorm:"not null;default:0;comment:从上一点位走到本点位的预计分钟数(0为未设置)" json:"duration"`
+}
@@ -13,6 +13,7 @@ type Team struct {
 	Password   string    `gorm:"size:64;not null;comment:团队加入密码"`
 	Slogan     string    `gorm:"size:128;comment:团队标语"`
 	AllowMatch bool      `gorm:"not null;default:false;comment:是否允许随机匹配"`
+	InviteOnly bool      `gorm:"not null;default:false;comment:是否仅允许通过邀请加入"`
 	Captain    string    `gorm:"size:64;not null;comment:队长OpenID"`
 	Route      uint8     `gorm:"not null;comment:路线(1朝晖,2屏峰半程,3屏峰全程,4莫干山半程,5莫干山全程)"`
 	Point      int8      `gorm:"default:0;comment:点位"`
@@ -22,6 +23,7 @@ type Team struct {
 	Code       string    `gorm:"size:128;index;comment:签到二维码绑定码"`
 	Time       time.Time `gorm:"comment:队伍状态更新时间"`
 	IsLost     bool      `gorm:"not null;default:false;comment:是否失联"`
+	LostReason string    `gorm:"size:255;comment:失联原因"`
 }
 
 func GetTeamInfo(teamID uint) (*Team, error) {
@@ -3,18 +3,21 @@ package router
 import (
 	"walk-server/controller/admin"
 	"walk-server/controller/basic"
+	"walk-server/controller/file"
 	"walk-server/controller/message"
 	"walk-server/controller/poster"
 	"walk-server/controller/register"
 	"walk-server/controller/team"
 	"walk-server/controller/user"
 	"walk-server/middleware"
+	"walk-server/service/adminService"
 
 	"github.com/gin-gonic/gin"
 )
 
 func MountRoutes(router *gin.Engine) {
-	router.POST("/api/v1/redis2mysql", middleware.TokenRateLimiter, team.RedisToMysql) // 从 Redis 中导入数据到 MySQL
+	router.GET("/file/*path", file.Download) // 通过签名链接下载生成的文件
+
 	api := router.Group("/api/v1", middleware.TokenRateLimiter)
 	{
 		if !gin.IsDebugging() {
@@ -56,19 +59,32 @@ func MountRoutes(router *gin.Engine) {
 			teamApi.POST("/create", team.CreateTeam)                            // 创建团队
 			teamApi.POST("/update", team.UpdateTeam)                            // 修改队伍信息
 			teamApi.POST("/join", team.JoinTeam)                                // 加入团队
+			teamApi.POST("/join/invite", team.JoinByInvite)                     // 通过邀请加入团队
+			teamApi.POST("/invite/create", team.CreateInvite)                   // 生成邀请
+			teamApi.GET("/invite/list", team.ListInvite)                        // 获取队伍的邀请
+			teamApi.POST("/invite/revoke", team.RevokeInvite)                   // 撤销邀请
+			teamApi.GET("/request/list", team.ListJoinRequest)                  // 获取待处理的加入申请
+			teamApi.POST("/request/approve", team.ApproveJoinRequest)           // 通过加入申请
+			teamApi.POST("/request/reject", team.RejectJoinRequest)             // 拒绝加入申请
+			teamApi.GET("/request/mine", team.GetMyJoinRequest)                 // 获取自己的加入申请
+			teamApi.GET("/request/cancel", team.CancelJoinRequest)              // 取消加入申请
 			teamApi.GET("/leave", middleware.IsExpired, team.LeaveTeam)         // 离开团队
 			teamApi.GET("/remove", middleware.IsExpired, team.RemoveMember)     // 移除队员
 			teamApi.GET("/add", middleware.IsExpired, team.AddMember)           // 添加队员
 			teamApi.GET("/disband", middleware.IsExpired, team.DisbandTeam)     // 解散团队
 			teamApi.GET("/rollback", middleware.IsExpired, team.RollBackTeam)   // 撤销提交
 			teamApi.POST("/captain", middleware.IsExpired, team.ChangeCaptain)  // 更换队长
+			teamApi.GET("/waitlist", team.GetWaitlist)                          // 获取候补状态
+			teamApi.GET("/waitlist/cancel", team.CancelWaitlist)                // 取消候补
 		}
 
 		// 事件相关的 API
 		messageApi := api.Group("/message", middleware.IsRegistered, middleware.PerRateLimiter)
 		{
-			messageApi.GET("/list", message.ListMessage)                            // 获取所有的消息
-			messageApi.POST("/delete", middleware.IsExpired, message.DeleteMessage) // 读了消息以后删除消息
+			messageApi.GET("/list", message.ListMessage)                            // 分页获取消息
+			messageApi.GET("/unread", message.GetUnread)                            // 获取未读消息数量
+			messageApi.POST("/read", message.ReadMessage)                           // 标记消息为已读
+			messageApi.POST("/delete", middleware.IsExpired, message.DeleteMessage) // 删除（归档）消息
 		}
 
 		// 海报相关的 API
@@ -78,33 +94,107 @@ func MountRoutes(router *gin.Engine) {
 		}
 	}
 
-	adminApi := router.Group("/api/v1/admin", middleware.TokenRateLimiter)
+	adminApi := router.Group("/api/v1/admin", middleware.TokenRateLimiter, middleware.Audit)
 	{
-		adminApi.POST("/auth", admin.AuthByPassword)                                     // 微信登录
-		adminApi.POST("/auth/auto", admin.WeChatLogin)                                   // 自动登录
-		adminApi.POST("/auth/without", admin.AuthWithoutCode)                            // 测试登录
-		adminApi.GET("/team/status", middleware.CheckAdmin, admin.GetTeam)               // 获取队伍信息
-		adminApi.POST("/team/user_status", middleware.CheckAdmin, admin.UserStatus)      // 更新用户状态
-		adminApi.POST("/team/bind", middleware.CheckAdmin, admin.BindTeam)               // 绑定队伍
-		adminApi.POST("/team/update", middleware.CheckAdmin, admin.UpdateTeamStatus)     // 更新队伍状态
-		adminApi.POST("/team/destination", middleware.CheckAdmin, admin.PostDestination) // 提交终点
-		adminApi.POST("/team/secret", admin.BlockWithSecret)                             // 通过密钥封禁接口
-		adminApi.POST("/team/regroup", middleware.CheckAdmin, admin.Regroup)             // 重新分组
-		adminApi.POST("/team/submit", middleware.CheckAdmin, admin.SubmitTeam)           // 提交团队
-		adminApi.GET("/detail", admin.GetDetail)                                         // 获取路线人员详情
-		adminApi.GET("/submit", admin.GetSubmitDetail)                                   // 获取报名人员列表
-		adminApi.GET("/timeout", admin.GetTimeoutUsers)                                  // 获取超时未提交的用户
-		adminApi.GET("/timeout/download", admin.DownloadTimeoutUsers)                    // 下载超时未提交的用户
-		adminApi.GET("/team/status/secret", admin.GetTeamBySecret)                       // 通过密钥获取队伍信息
-		adminApi.POST("/route/create", admin.CreateRouteAdmin)                           // 创建路线管理员
-		adminApi.POST("/team/lost", admin.SetTeamLost)                                   // 设置队伍失联状态
-		adminApi.GET("/team/lost", admin.GetLostTeams)                                   // 获取所有失联的队伍
-		adminApi.GET("/team/wrong-route", admin.GetWrongRouteTeams)                      // 获取走错路线的队伍数量
+		adminApi.POST("/auth", admin.AuthByPassword)                                   // 微信登录
+		adminApi.POST("/auth/auto", admin.WeChatLogin)                                 // 自动登录
+		adminApi.POST("/auth/without", admin.AuthWithoutCode)                          // 测试登录
+		adminApi.POST("/auth/refresh", admin.RefreshToken)                             // 刷新登录凭证
+		adminApi.POST("/auth/logout", middleware.CheckAdmin, admin.Logout)             // 退出登录
+		adminApi.GET("/permission", middleware.CheckAdmin, admin.GetPermission)        // 获取当前管理员的角色和权限
+		adminApi.POST("/password/change", middleware.CheckAdmin, admin.ChangePassword) // 修改密码
+		adminApi.GET("/session/list", middleware.CheckAdmin, admin.GetSessions)        // 获取登录设备
+		adminApi.POST("/session/revoke", middleware.CheckAdmin, admin.RevokeSession)   // 将登录设备踢下线
+
+		// 扫码相关的 API，所有管理员都可以使用
+		scanApi := adminApi.Group("", middleware.CheckAdmin, middleware.RequirePermission(adminService.PermScan))
+		{
+			scanApi.GET("/team/status", admin.GetTeam)               // 获取队伍信息
+			scanApi.POST("/team/user_status", admin.UserStatus)      // 更新用户状态
+			scanApi.POST("/team/bind", admin.BindTeam)               // 绑定队伍
+			scanApi.POST("/team/update", admin.UpdateTeamStatus)     // 更新队伍状态
+			scanApi.POST("/team/destination", admin.PostDestination) // 提交终点
+			scanApi.GET("/team/timeline", admin.GetTeamTimeline)     // 获取队伍打卡记录
+			scanApi.GET("/eta", admin.GetETA)                        // 获取各点位预计到达的队伍
+		}
+
+		// 队伍管理相关的 API
+		teamApi := adminApi.Group("", middleware.CheckAdmin, middleware.RequirePermission(adminService.PermTeamManage))
+		{
+			teamApi.GET("/team/info", admin.GetTeamInfo)                    // 通过队伍编号获取队伍详情
+			teamApi.POST("/team/regroup", admin.Regroup)                    // 重新分组
+			teamApi.POST("/team/submit", admin.SubmitTeam)                  // 提交团队
+			teamApi.POST("/team/lost", admin.SetTeamLost)                   // 设置队伍失联状态
+			teamApi.POST("/team/checkpoint/revert", admin.RevertCheckpoint) // 撤销队伍最后一次打卡
+		}
+
+		// 统计相关的 API
+		detailApi := adminApi.Group("", middleware.CheckAdmin, middleware.RequirePermission(adminService.PermDetail))
+		{
+			detailApi.GET("/team/lost", admin.GetLostTeams)                // 获取所有失联的队伍
+			detailApi.GET("/team/wrong-route", admin.GetWrongRouteTeams)   // 获取走错路线的队伍数量
+			detailApi.GET("/detail", admin.GetDetail)                      // 获取路线人员详情
+			detailApi.GET("/detail/stream", admin.GetDetailStream)         // 实时推送路线人员详情
+			detailApi.GET("/submit", admin.GetSubmitDetail)                // 获取报名人员列表
+			detailApi.GET("/timeout", admin.GetTimeoutUsers)               // 获取超时未提交的用户
+			detailApi.GET("/timeout/download", admin.DownloadTimeoutUsers) // 下载超时未提交的用户
+			detailApi.GET("/roster/export", admin.ExportRoster)            // 导出已提交队伍的完整名单
+		}
+
+		// 管理员管理相关的 API
+		adminManageApi := adminApi.Group("", middleware.CheckAdmin, middleware.RequirePermission(adminService.PermAdminManage))
+		{
+			adminManageApi.POST("/route/create", admin.CreateRouteAdmin) // 创建路线管理员
+			adminManageApi.POST("/role", admin.SetAdminRole)             // 修改管理员角色
+			adminManageApi.POST("/password/reset", admin.ResetPassword)  // 重置管理员密码
+		}
+
+		// 路线目录相关的 API
+		routeApi := adminApi.Group("", middleware.CheckAdmin, middleware.RequirePermission(adminService.PermRouteManage))
+		{
+			routeApi.GET("/route/list", admin.GetRoutes)      // 获取路线目录
+			routeApi.POST("/route/save", admin.SaveRoute)     // 新建或修改路线
+			routeApi.POST("/route/delete", admin.DeleteRoute) // 删除路线
+		}
+
+		// 名额和对账相关的 API
+		quotaApi := adminApi.Group("", middleware.CheckAdmin, middleware.RequirePermission(adminService.PermQuota))
+		{
+			quotaApi.GET("/quota/list", admin.GetQuotas)   // 获取每天各路线名额
+			quotaApi.POST("/quota/set", admin.SetQuota)    // 设置总名额
+			quotaApi.POST("/quota/add", admin.AddQuota)    // 追加名额
+			quotaApi.GET("/quota/log", admin.GetQuotaLogs) // 获取名额调整记录
+			quotaApi.GET("/reconcile", admin.GetReconcile) // 对比 Redis 和 MySQL 的提交状态
+			quotaApi.POST("/reconcile", admin.Reconcile)   // 修正 Redis 和 MySQL 的提交状态
+		}
+
+		// 广播相关的 API
+		broadcastApi := adminApi.Group("", middleware.CheckAdmin, middleware.RequirePermission(adminService.PermBroadcast))
+		{
+			broadcastApi.POST("/broadcast/send", admin.SendBroadcast)       // 发送广播
+			broadcastApi.GET("/broadcast/list", admin.GetBroadcasts)        // 获取广播列表
+			broadcastApi.GET("/broadcast/detail", admin.GetBroadcastDetail) // 获取广播的发送结果
+			broadcastApi.GET("/notify/dead", admin.GetDeadNotifications)    // 获取推送失败的通知
+		}
+
+		// 操作记录相关的 API
+		auditApi := adminApi.Group("", middleware.CheckAdmin, middleware.RequirePermission(adminService.PermAudit))
+		{
+			auditApi.GET("/audit/list", admin.GetAuditLogs)      // 查询管理员操作记录
+			auditApi.GET("/audit/export", admin.ExportAuditLogs) // 导出管理员操作记录
+		}
+
+		// 人员导入相关的 API
+		importApi := adminApi.Group("", middleware.CheckAdmin, middleware.RequirePermission(adminService.PermUserImport))
+		{
+			importApi.POST("/user/import", admin.ImportAlumni) // 从 Excel 导入校友
+		}
 
 		if gin.IsDebugging() {
-			adminApi.POST("/test/create", admin.CreateTestTeams) // 创建测试队伍
-			adminApi.POST("/test/delete", admin.DeleteTestTeams) // 删除测试队伍
-			adminApi.POST("/test/update", admin.UpdateTestTeams) // 更新测试队伍
+			testApi := adminApi.Group("/test", middleware.CheckAdmin, middleware.RequirePermission(adminService.PermTest))
+			testApi.POST("/create", admin.CreateTestTeams) // 创建测试队伍
+			testApi.POST("/delete", admin.DeleteTestTeams) // 删除测试队伍
+			testApi.POST("/update", admin.UpdateTestTeams) // 更新测试队伍
 		}
 	}
 }
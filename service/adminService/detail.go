@@ -0,0 +1,120 @@
+package adminService
+
+import (
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/service/routeService"
+)
+
+type RouteDetail struct {
+	Count2 *int64 `json:"count2,omitempty"`
+	Count  int64  `json:"count"`
+	Label  string `json:"label"`
+}
+
+// GetRouteDetail 获取一条路线各点位的人数
+// 依次为 已出发、未出发、各点位（不含终点）、已结束、下撤
+func GetRouteDetail(route uint8) []RouteDetail {
+	endPoint := int(routeService.GetEndPoint(route))
+	points := make([]int64, endPoint+3)
+
+	// 获取各点位人数（当前在该点位的人数）
+	var pointCounts []struct {
+		Point int64
+		Count int64
+	}
+	global.DB.Model(&model.Person{}).
+		Select("teams.point, count(*) as count").
+		Joins("JOIN teams ON people.team_id = teams.id").
+		Where("teams.route = ? AND people.walk_status IN ? AND teams.status IN ?", route, []int{2, 3}, []int{2, 5}).
+		Group("teams.point").
+		Order("teams.point").
+		Scan(&pointCounts)
+	for _, pointCount := range pointCounts {
+		if pointCount.Point >= 0 && int(pointCount.Point) < endPoint+1 {
+			points[pointCount.Point+1] = pointCount.Count
+		}
+	}
+
+	// 获取未开始人数
+	global.DB.Model(&model.Person{}).
+		Select("count(*) as count").
+		Joins("JOIN teams ON people.team_id = teams.id").
+		Where("teams.route = ? AND people.walk_status = 1 And teams.submit = 1", route).
+		Pluck("count", &points[0])
+
+	// 获取已结束和下撤人数
+	var endCount5, endCount4 int64
+	global.DB.Model(&model.Person{}).
+		Select("count(*) as count").
+		Joins("JOIN teams ON people.team_id = teams.id").
+		Where("teams.route = ? AND people.walk_status = 5", route).
+		Pluck("count", &endCount5)
+	global.DB.Model(&model.Person{}).
+		Select("count(*) as count").
+		Joins("JOIN teams ON people.team_id = teams.id").
+		Where("teams.route = ? AND people.walk_status = 4", route).
+		Pluck("count", &endCount4)
+	points[len(points)-2] = endCount5
+	points[len(points)-1] = endCount4
+
+	// 先计算每个点位的count2（经过该点位的总人数）
+	count2Array := make([]int64, len(points))
+	for i := len(points) - 3; i >= 0; i-- { // 从倒数第三个开始向前累加
+		if i == len(points)-3 {
+			count2Array[i] = points[i]
+		} else {
+			count2Array[i] = points[i] + count2Array[i+1]
+		}
+	}
+
+	details := make([]RouteDetail, len(points))
+	var startedTotal int64
+	for i, count := range points {
+		label := ""
+		switch i {
+		case 0:
+			label = "未出发"
+		case len(points) - 2:
+			label = "已结束"
+		case len(points) - 1:
+			label = "下撤"
+		default:
+			label = routeService.GetPointName(route, int8(i-1))
+		}
+
+		// 只有普通点位需要count2
+		if i != 0 && i != len(points)-2 && i != len(points)-1 {
+			details[i] = RouteDetail{
+				Count:  count,
+				Count2: &count2Array[i],
+				Label:  label,
+			}
+		} else {
+			details[i] = RouteDetail{
+				Count: count,
+				Label: label,
+			}
+		}
+
+		// 计算除了"未出发"之外的所有人员数量
+		if i > 0 {
+			startedTotal += count
+		}
+	}
+
+	// 添加已出发总人数标签
+	return append([]RouteDetail{{
+		Count: startedTotal,
+		Label: "已出发",
+	}}, details...)
+}
+
+// GetAllRouteDetail 获取全部路线的点位人数，键为路线标识
+func GetAllRouteDetail() map[string][]RouteDetail {
+	result := make(map[string][]RouteDetail)
+	for _, route := range routeService.GetRoutes() {
+		result[route.Code] = GetRouteDetail(route.ID)
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+package adminService
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+	"walk-server/global"
+	"walk-server/service/routeService"
+)
+
+// 扫码后通过 Redis 广播发生变化的路线，多个实例都能收到
+const detailChannel = "admin:detail:changed"
+
+// DetailUpdate 推送给看板的增量数据，键为路线标识
+type DetailUpdate map[string][]RouteDetail
+
+var (
+	feedMu      sync.Mutex
+	subscribers = make(map[chan DetailUpdate]struct{})
+	dirtyRoutes = make(map[uint8]struct{})
+)
+
+// NotifyDetailChanged 通知看板某条路线的人数发生了变化
+func NotifyDetailChanged(route uint8) {
+	if err := global.Rdb.Publish(global.Rctx, detailChannel, route).Err(); err != nil {
+		log.Println(err)
+	}
+}
+
+// SubscribeDetail 订阅看板的增量数据，返回的函数用于取消订阅
+// 订阅者处理过慢时通道会被关闭，客户端重连后重新获取全量数据
+func SubscribeDetail() (<-chan DetailUpdate, func()) {
+	ch := make(chan DetailUpdate, 16)
+	feedMu.Lock()
+	subscribers[ch] = struct{}{}
+	feedMu.Unlock()
+
+	return ch, func() {
+		feedMu.Lock()
+		defer feedMu.Unlock()
+		if _, ok := subscribers[ch]; ok {
+			delete(subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// RunDetailFeed 收集变化的路线，每秒重新统计一次并推送给所有订阅者
+func RunDetailFeed() {
+	pubsub := global.Rdb.Subscribe(global.Rctx, detailChannel)
+	go func() {
+		for msg := range pubsub.Channel() {
+			route, err := strconv.Atoi(msg.Payload)
+			if err != nil {
+				continue
+			}
+			feedMu.Lock()
+			dirtyRoutes[uint8(route)] = struct{}{}
+			feedMu.Unlock()
+		}
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		feedMu.Lock()
+		routes := make([]uint8, 0, len(dirtyRoutes))
+		for route := range dirtyRoutes {
+			routes = append(routes, route)
+		}
+		clear(dirtyRoutes)
+		hasSubscriber := len(subscribers) > 0
+		feedMu.Unlock()
+
+		// 没有人订阅时不查询数据库
+		if !hasSubscriber || len(routes) == 0 {
+			continue
+		}
+
+		update := make(DetailUpdate, len(routes))
+		for _, id := range routes {
+			if route, ok := routeService.GetRoute(id); ok {
+				update[route.Code] = GetRouteDetail(id)
+			}
+		}
+
+		feedMu.Lock()
+		for ch := range subscribers {
+			select {
+			case ch <- update:
+			default:
+				delete(subscribers, ch)
+				close(ch)
+			}
+		}
+		feedMu.Unlock()
+	}
+}
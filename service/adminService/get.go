@@ -1,8 +1,8 @@
 package adminService
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
-	"strconv"
 	"time"
 	"walk-server/global"
 	"walk-server/model"
@@ -51,16 +51,22 @@ func GetAdminByID(id uint) (*model.Admin, error) {
 	return &user, nil
 }
 
+// GetAdminByJWT 获取当前请求的管理员，经过 CheckAdmin 的请求直接使用其解析出的管理员
+// 管理员信息来自 token，不包含密码等字段，需要时使用 GetAdminByID 查询
 func GetAdminByJWT(context *gin.Context) (*model.Admin, error) {
-	jwtData := utility.GetJwtData(context)
-	// jwt token 解析失败
-	userID := utility.AesDecrypt(jwtData.OpenID, global.Config.GetString("server.AESSecret"))
-	user_id, err := strconv.Atoi(userID)
-	user, err := GetAdminByID(uint(user_id))
+	if value, exists := context.Get("admin"); exists {
+		return value.(*model.Admin), nil
+	}
+
+	jwtToken := context.GetHeader("Authorization")
+	if len(jwtToken) < 7 {
+		return nil, errors.New("no token")
+	}
+	claims, err := utility.ParseAdminToken(jwtToken[7:], utility.AdminAccessToken)
 	if err != nil {
 		return nil, err
 	}
-	return user, err
+	return AdminFromClaims(claims), nil
 }
 
 func GetTimeoutTeams(min int, route uint8) (map[int8][]model.Team, error) {
@@ -0,0 +1,37 @@
+package adminService
+
+import (
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/utility"
+)
+
+// SetPassword 保存新密码的哈希，mustChange 为 true 时下次登录后需要先修改密码
+func SetPassword(id uint, password string, mustChange bool) error {
+	hash, err := utility.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return global.DB.Model(&model.Admin{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password":             hash,
+		"must_change_password": mustChange,
+	}).Error
+}
+
+// MigratePasswords 将仍以明文保存的密码转换为哈希，转换后需要修改密码
+func MigratePasswords() error {
+	var admins []model.Admin
+	if err := global.DB.Where("password <> ''").Find(&admins).Error; err != nil {
+		return err
+	}
+
+	for _, admin := range admins {
+		if utility.IsPasswordHashed(admin.Password) {
+			continue
+		}
+		if err := SetPassword(admin.ID, admin.Password, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package adminService
+
+import (
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/service/routeService"
+)
+
+// 管理端接口需要的权限
+const (
+	PermScan          = "scan"           // 扫码打卡、查看队伍打卡记录
+	PermDetail        = "detail"         // 查看各路线人数、报名和超时统计
+	PermTeamManage    = "team:manage"    // 查看队伍详情、重新分组、提交、标记失联
+	PermRouteManage   = "route:manage"   // 管理路线目录
+	PermQuota         = "quota"          // 调整名额、对账
+	PermAdminManage   = "admin:manage"   // 创建管理员、修改角色
+	PermSessionManage = "session:manage" // 将其他管理员的登录设备踢下线
+	PermAudit         = "audit"          // 查看和导出管理员操作记录
+	PermBroadcast     = "broadcast"      // 向队伍成员发送广播
+	PermUserImport    = "user:import"    // 从 Excel 批量导入校友
+	PermTest          = "test"           // 测试数据
+)
+
+var rolePermissions = map[uint8][]string{
+	model.RoleSuper:     {PermScan, PermDetail, PermTeamManage, PermRouteManage, PermQuota, PermAdminManage, PermSessionManage, PermAudit, PermBroadcast, PermUserImport, PermTest},
+	model.RoleRouteLead: {PermScan, PermDetail, PermTeamManage, PermSessionManage, PermBroadcast},
+	model.RoleStaff:     {PermScan},
+}
+
+// GetPermissions 获取角色拥有的权限
+func GetPermissions(role uint8) []string {
+	if permissions, ok := rolePermissions[role]; ok {
+		return permissions
+	}
+	return []string{}
+}
+
+// HasPermission 判断角色是否拥有权限
+func HasPermission(role uint8, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManageRoute 超级管理员可以管理所有路线，其他管理员只能管理所在片区的路线
+func CanManageRoute(admin *model.Admin, route uint8) bool {
+	return admin.Role == model.RoleSuper || routeService.IsSameArea(route, admin.Route)
+}
+
+// PromoteSuperAdmins 将 admin.superAccounts 中配置的账号设为超级管理员
+func PromoteSuperAdmins() error {
+	accounts := global.Config.GetStringSlice("admin.superAccounts")
+	if len(accounts) == 0 {
+		return nil
+	}
+	return global.DB.Model(&model.Admin{}).Where("account IN ?", accounts).Update("role", model.RoleSuper).Error
+}
+
+// UpdateRole 修改管理员角色
+func UpdateRole(id uint, role uint8) error {
+	return global.DB.Model(&model.Admin{}).Where("id = ?", id).Update("role", role).Error
+}
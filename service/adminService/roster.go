@@ -0,0 +1,107 @@
+package adminService
+
+import (
+	"strconv"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/service/quotaService"
+
+	"gorm.io/gorm"
+)
+
+// rosterBatchSize 导出名单时每次从数据库读取的人数
+const rosterBatchSize = 1000
+
+type RosterFilter struct {
+	Day    *uint8 // 出发日期（从 0 开始），为空时不筛选
+	Campus uint8  // 校区，0 为不筛选
+	Type   uint8  // 参与者类型，0 为不筛选
+}
+
+// RosterMember 名单中的一名成员
+type RosterMember struct {
+	model.Person `gorm:"embedded"`
+	TeamName     string
+	Day          *uint8 // 队伍占用名额的日期，管理员提交的队伍和旧数据没有
+}
+
+// SubmittedTeams 已提交的队伍ID -> 占用名额的日期（可能为空）
+type SubmittedTeams map[uint]*uint8
+
+// GetSubmittedTeams 从 Redis 获取已提交的队伍及其占用名额的日期，提交状态以 Redis 为准
+func GetSubmittedTeams() (SubmittedTeams, error) {
+	teamIDs, err := global.Rdb.SMembers(global.Rctx, "teams").Result()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := global.Rdb.HGetAll(global.Rctx, quotaService.SubmittedKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	teams := make(SubmittedTeams, len(teamIDs))
+	for _, teamID := range teamIDs {
+		id, err := strconv.Atoi(teamID)
+		if err != nil {
+			continue
+		}
+		teams[uint(id)] = nil
+		if key, ok := keys[teamID]; ok {
+			if day, _, err := quotaService.ParseKey(key); err == nil {
+				teams[uint(id)] = &day
+			}
+		}
+	}
+	return teams, nil
+}
+
+// EachRosterBatch 分批读取一条路线已提交队伍的成员，按队伍编号排序，队长排在队员前面
+// submitted 为 GetSubmittedTeams 的结果，用于确定已提交的队伍、按日期筛选和填充出发日期
+func EachRosterBatch(route uint8, filter RosterFilter, submitted SubmittedTeams, fn func([]RosterMember) error) error {
+	teamIDs := make([]uint, 0, len(submitted))
+	for teamID, day := range submitted {
+		if filter.Day == nil || (day != nil && *day == *filter.Day) {
+			teamIDs = append(teamIDs, teamID)
+		}
+	}
+	if len(teamIDs) == 0 {
+		return nil
+	}
+
+	query := global.DB.Model(&model.Person{}).
+		Select("people.*, teams.name AS team_name").
+		Joins("JOIN teams ON people.team_id = teams.id").
+		Where("teams.route = ? AND teams.id IN ?", route, teamIDs)
+	if filter.Campus != 0 {
+		query = query.Where("people.campus = ?", filter.Campus)
+	}
+	if filter.Type != 0 {
+		query = query.Where("people.type = ?", filter.Type)
+	}
+
+	// 按队伍和成员的顺序翻页，避免一次性加载所有成员
+	query = query.Session(&gorm.Session{})
+	offset := 0
+	for {
+		var members []RosterMember
+		err := query.Order("people.team_id, people.status DESC, people.open_id").
+			Offset(offset).Limit(rosterBatchSize).
+			Find(&members).Error
+		if err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			return nil
+		}
+		for i := range members {
+			members[i].Day = submitted[uint(members[i].TeamId)]
+		}
+		if err := fn(members); err != nil {
+			return err
+		}
+		if len(members) < rosterBatchSize {
+			return nil
+		}
+		offset += rosterBatchSize
+	}
+}
@@ -0,0 +1,223 @@
+package adminService
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/utility"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	sessionPrefix = "admin:sessions:" // 管理员的登录会话（哈希，会话ID -> 会话信息）
+	revokedPrefix = "admin:revoked:"  // 已吊销的会话ID
+)
+
+var ErrSessionRevoked = errors.New("session revoked")
+
+// Session 管理员的一次登录
+type Session struct {
+	ID          string    `json:"sid"`
+	Device      string    `json:"device"`
+	IP          string    `json:"ip"`
+	LoginTime   time.Time `json:"login_time"`
+	RefreshTime time.Time `json:"refresh_time"`
+}
+
+// Tokens 登录或刷新后下发的 token
+type Tokens struct {
+	Access    string    `json:"jwt"`
+	Refresh   string    `json:"refresh_token"`
+	ExpiresAt time.Time `json:"expires_at"` // access token 的过期时间
+}
+
+// AccessTTL access token 有效期，由 admin.accessTTL 配置（分钟），默认 30 分钟
+func AccessTTL() time.Duration {
+	ttl := global.Config.GetInt("admin.accessTTL")
+	if ttl <= 0 {
+		ttl = 30
+	}
+	return time.Duration(ttl) * time.Minute
+}
+
+// RefreshTTL refresh token 有效期，由 admin.refreshTTL 配置（小时），默认 7 天
+func RefreshTTL() time.Duration {
+	ttl := global.Config.GetInt("admin.refreshTTL")
+	if ttl <= 0 {
+		ttl = 24 * 7
+	}
+	return time.Duration(ttl) * time.Hour
+}
+
+func sessionKey(adminID uint) string {
+	return sessionPrefix + strconv.Itoa(int(adminID))
+}
+
+// Login 为管理员创建新的登录会话并下发 token
+func Login(admin *model.Admin, device string, ip string) (*Tokens, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	session := Session{
+		ID:          hex.EncodeToString(b),
+		Device:      device,
+		IP:          ip,
+		LoginTime:   time.Now(),
+		RefreshTime: time.Now(),
+	}
+	if err := saveSession(admin.ID, &session); err != nil {
+		return nil, err
+	}
+	return issueTokens(admin, session.ID)
+}
+
+// Refresh 使用 refresh token 换取新的 token，会重新读取管理员信息以便角色修改生效
+func Refresh(refreshToken string) (*model.Admin, *Tokens, error) {
+	claims, err := utility.ParseAdminToken(refreshToken, utility.AdminRefreshToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	if IsRevoked(claims.SessionID) {
+		return nil, nil, ErrSessionRevoked
+	}
+
+	data, err := global.Rdb.HGet(global.Rctx, sessionKey(claims.AdminID), claims.SessionID).Bytes()
+	if err != nil {
+		return nil, nil, ErrSessionRevoked
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, nil, err
+	}
+
+	admin, err := GetAdminByID(claims.AdminID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session.RefreshTime = time.Now()
+	if err := saveSession(admin.ID, &session); err != nil {
+		return nil, nil, err
+	}
+	tokens, err := issueTokens(admin, session.ID)
+	return admin, tokens, err
+}
+
+func issueTokens(admin *model.Admin, sid string) (*Tokens, error) {
+	claims := AdminClaims(admin, sid)
+	claims.Type = utility.AdminAccessToken
+	access, err := utility.GenerateAdminJwt(claims, AccessTTL())
+	if err != nil {
+		return nil, err
+	}
+
+	claims = AdminClaims(admin, sid)
+	claims.Type = utility.AdminRefreshToken
+	refresh, err := utility.GenerateAdminJwt(claims, RefreshTTL())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tokens{
+		Access:    access,
+		Refresh:   refresh,
+		ExpiresAt: time.Now().Add(AccessTTL()),
+	}, nil
+}
+
+func saveSession(adminID uint, session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	pipe := global.Rdb.TxPipeline()
+	pipe.HSet(global.Rctx, sessionKey(adminID), session.ID, data)
+	pipe.Expire(global.Rctx, sessionKey(adminID), RefreshTTL())
+	_, err = pipe.Exec(global.Rctx)
+	return err
+}
+
+// AdminClaims 根据管理员信息生成 token 数据
+func AdminClaims(admin *model.Admin, sid string) *utility.AdminClaims {
+	return &utility.AdminClaims{
+		AdminID:            admin.ID,
+		Name:               admin.Name,
+		Role:               admin.Role,
+		Route:              admin.Route,
+		Point:              admin.Point,
+		MustChangePassword: admin.MustChangePassword,
+		SessionID:          sid,
+	}
+}
+
+// AdminFromClaims 根据 token 数据还原管理员信息，不包含密码等敏感字段
+func AdminFromClaims(claims *utility.AdminClaims) *model.Admin {
+	return &model.Admin{
+		ID:                 claims.AdminID,
+		Name:               claims.Name,
+		Role:               claims.Role,
+		Route:              claims.Route,
+		Point:              claims.Point,
+		MustChangePassword: claims.MustChangePassword,
+	}
+}
+
+// IsRevoked 会话是否已被吊销
+func IsRevoked(sid string) bool {
+	n, err := global.Rdb.Exists(global.Rctx, revokedPrefix+sid).Result()
+	return err != nil || n > 0
+}
+
+// GetSessions 按登录时间倒序获取管理员仍然有效的登录会话
+func GetSessions(adminID uint) ([]Session, error) {
+	values, err := global.Rdb.HGetAll(global.Rctx, sessionKey(adminID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(values))
+	for sid, data := range values {
+		var session Session
+		if err := json.Unmarshal([]byte(data), &session); err != nil || time.Since(session.RefreshTime) > RefreshTTL() {
+			// refresh token 已过期的会话直接清理
+			global.Rdb.HDel(global.Rctx, sessionKey(adminID), sid)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LoginTime.After(sessions[j].LoginTime)
+	})
+	return sessions, nil
+}
+
+// RevokeSession 吊销管理员的一个登录会话，该会话的 token 立即失效，返回会话是否存在
+func RevokeSession(adminID uint, sid string) (bool, error) {
+	n, err := global.Rdb.HDel(global.Rctx, sessionKey(adminID), sid).Result()
+	if err != nil || n == 0 {
+		return false, err
+	}
+	// 保留到 refresh token 过期为止
+	return true, global.Rdb.Set(global.Rctx, revokedPrefix+sid, adminID, RefreshTTL()).Err()
+}
+
+// RevokeAllSessions 吊销管理员的所有登录会话，用于修改角色、重置密码等需要重新登录的场景
+func RevokeAllSessions(adminID uint) error {
+	sids, err := global.Rdb.HKeys(global.Rctx, sessionKey(adminID)).Result()
+	if err != nil {
+		return err
+	}
+	for _, sid := range sids {
+		if _, err := RevokeSession(adminID, sid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package broadcastService
+
+import (
+	"errors"
+	"log"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/utility"
+)
+
+var ErrNoRecipient = errors.New("no recipient")
+
+// SelectRecipients 获取符合条件的队伍中的全部成员
+func SelectRecipients(filter model.BroadcastFilter) ([]model.Person, error) {
+	query := global.DB.Model(&model.Team{})
+	if len(filter.Routes) > 0 {
+		query = query.Where("route IN ?", filter.Routes)
+	}
+	if filter.PointFrom != nil {
+		query = query.Where("point >= ?", *filter.PointFrom)
+	}
+	if filter.PointTo != nil {
+		query = query.Where("point < ?", *filter.PointTo)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.IsLost != nil {
+		query = query.Where("is_lost = ?", *filter.IsLost)
+	}
+	if len(filter.TeamIDs) > 0 {
+		query = query.Where("id IN ?", filter.TeamIDs)
+	}
+
+	var teamIDs []uint
+	if err := query.Pluck("id", &teamIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(teamIDs) == 0 {
+		return nil, nil
+	}
+
+	personQuery := global.DB.Where("team_id IN ?", teamIDs)
+	if len(filter.WalkStatuses) > 0 {
+		personQuery = personQuery.Where("walk_status IN ?", filter.WalkStatuses)
+	}
+	var persons []model.Person
+	err := personQuery.Order("team_id").Find(&persons).Error
+	return persons, err
+}
+
+// NotifyKind 广播推送结束时回调 OnDelivered
+const NotifyKind = "broadcast"
+
+// Send 写入站内消息并将推送放入通知队列，推送结果记录在 BroadcastReceipt 中
+func Send(admin *model.Admin, message string, channel string, filter model.BroadcastFilter) (*model.Broadcast, error) {
+	persons, err := SelectRecipients(filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(persons) == 0 {
+		return nil, ErrNoRecipient
+	}
+
+	receipts := make([]model.BroadcastReceipt, 0, len(persons))
+	for _, person := range persons {
+		receipts = append(receipts, model.BroadcastReceipt{
+			TeamID: uint(person.TeamId),
+			OpenId: person.OpenId,
+			Name:   person.Name,
+		})
+	}
+
+	if channel == "" {
+		channel = utility.DefaultChannel()
+	}
+	broadcast := model.Broadcast{
+		AdminID:   admin.ID,
+		AdminName: admin.Name,
+		Message:   message,
+		Channel:   channel,
+		Filter:    filter,
+		Total:     len(receipts),
+	}
+	if err := model.CreateBroadcast(&broadcast, receipts); err != nil {
+		return nil, err
+	}
+
+	for _, receipt := range receipts {
+		err := utility.Enqueue(&utility.Notification{
+			Channel:  channel,
+			Receiver: receipt.OpenId,
+			Message:  message,
+			Kind:     NotifyKind,
+			RefID:    receipt.ID,
+		})
+		if err != nil {
+			OnDelivered(&utility.Notification{RefID: receipt.ID}, err)
+		} else if channel == utility.ChannelInApp {
+			OnDelivered(&utility.Notification{RefID: receipt.ID}, nil)
+		}
+	}
+	return &broadcast, nil
+}
+
+// OnDelivered 记录通知队列返回的推送结果
+func OnDelivered(n *utility.Notification, err error) {
+	status, errMsg := model.ReceiptSent, ""
+	if err != nil {
+		status, errMsg = model.ReceiptFailed, err.Error()
+		if runes := []rune(errMsg); len(runes) > 255 {
+			errMsg = string(runes[:255])
+		}
+	}
+	if err := model.UpdateReceipt(n.RefID, status, errMsg); err != nil {
+		log.Println(err)
+	}
+}
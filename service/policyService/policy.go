@@ -0,0 +1,89 @@
+package policyService
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"walk-server/global"
+	"walk-server/model"
+)
+
+// Policy 队伍组成规则，返回的错误信息可以直接展示给用户
+type Policy struct {
+	MinMembers               uint8   `mapstructure:"minMembers" json:"min_members"`                               // 提交队伍需要的最少人数，已提交的队伍也不能少于该人数
+	MaxMembers               uint8   `mapstructure:"maxMembers" json:"max_members"`                               // 队伍人数上限
+	TeacherJoinStudent       bool    `mapstructure:"teacherJoinStudent" json:"teacher_join_student"`              // 教职工能否加入学生为队长的队伍
+	StartRatio               float64 `mapstructure:"startRatio" json:"start_ratio"`                               // 起点扫码时到场人数占队伍人数的最低比例
+	StudentCaptainFromOthers bool    `mapstructure:"studentCaptainFromOthers" json:"student_captain_from_others"` // 教职工、校友队长能否将队长移交给学生
+}
+
+// defaultPolicy 未配置时使用的规则
+var defaultPolicy = Policy{
+	MinMembers:               4,
+	MaxMembers:               6,
+	TeacherJoinStudent:       false,
+	StartRatio:               0.5,
+	StudentCaptainFromOthers: false,
+}
+
+// GetPolicy 获取路线的队伍组成规则
+// 先读取 teamPolicy.default，再用 teamPolicy.routes.<路线ID> 中配置的字段覆盖，配置有误时使用默认规则
+func GetPolicy(route uint8) Policy {
+	policy := defaultPolicy
+	if err := global.Config.UnmarshalKey("teamPolicy.default", &policy); err != nil {
+		log.Println(err)
+		return defaultPolicy
+	}
+	if err := global.Config.UnmarshalKey("teamPolicy.routes."+strconv.Itoa(int(route)), &policy); err != nil {
+		log.Println(err)
+		return defaultPolicy
+	}
+	if policy.MinMembers == 0 || policy.MaxMembers < policy.MinMembers {
+		log.Printf("路线 %d 的队伍规则配置有误: %+v\n", route, policy)
+		return defaultPolicy
+	}
+	return policy
+}
+
+// CheckJoin 检查用户能否加入队伍，captain 为队伍当前的队长
+func (p Policy) CheckJoin(team *model.Team, captain *model.Person, person *model.Person) error {
+	if team.Num >= p.MaxMembers {
+		return fmt.Errorf("队伍人数已达上限%d人", p.MaxMembers)
+	}
+	if !p.TeacherJoinStudent && captain.Type == 1 && person.Type == 2 {
+		return fmt.Errorf("教职工无法加入学生队伍")
+	}
+	return nil
+}
+
+// CheckLeave 检查队员能否离开队伍，已提交的队伍人数不能少于最少人数
+func (p Policy) CheckLeave(team *model.Team, submitted bool) error {
+	if submitted && team.Num <= p.MinMembers {
+		return fmt.Errorf("队伍已提交，人数不能少于%d人", p.MinMembers)
+	}
+	return nil
+}
+
+// CheckSubmit 检查队伍人数能否提交
+func (p Policy) CheckSubmit(team *model.Team) error {
+	if team.Num < p.MinMembers {
+		return fmt.Errorf("队伍人数不足%d人", p.MinMembers)
+	} else if team.Num > p.MaxMembers {
+		return fmt.Errorf("队伍人数不能超过%d人", p.MaxMembers)
+	}
+	return nil
+}
+
+// CheckCaptain 检查当前队长 captain 能否将队长移交给 newCaptain
+func (p Policy) CheckCaptain(captain *model.Person, newCaptain *model.Person) error {
+	if !p.StudentCaptainFromOthers && captain.Type != 1 && newCaptain.Type == 1 {
+		return fmt.Errorf("无法将队长移交给学生")
+	}
+	return nil
+}
+
+// StartNum 起点扫码时至少需要到场的人数
+func (p Policy) StartNum(team *model.Team) uint {
+	return uint(math.Ceil(float64(team.Num) * p.StartRatio))
+}
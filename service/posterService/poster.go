@@ -0,0 +1,107 @@
+package posterService
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/service/routeService"
+	"walk-server/utility"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	posterDir        = utility.FileDir + "poster/"
+	posterKeyPrefix  = "poster:team:" // 队伍当前海报的哈希
+	posterKeyExpire  = 7 * 24 * time.Hour
+	posterLayoutHash = "v1" // 修改海报布局后需要修改，使旧的缓存失效
+)
+
+var ErrRouteNotFound = errors.New("route not found")
+
+// Poster 生成好的海报
+type Poster struct {
+	Hash string
+	Path string // 本地文件路径
+}
+
+func newPoster(hash string) *Poster {
+	return &Poster{Hash: hash, Path: filepath.Join(posterDir, hash+".png")}
+}
+
+// URL 生成海报有时效的访问链接
+func (p *Poster) URL() (string, error) {
+	return utility.SignFileURL(p.Path)
+}
+
+// hashPoster 根据海报内容计算哈希，内容相同的海报共用一个文件
+func hashPoster(data utility.PosterData) string {
+	h := sha256.New()
+	for _, s := range append([]string{posterLayoutHash, data.Template, data.Route, data.TeamName, data.Slogan}, data.Members...) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// Get 获取队伍的海报，队伍没有变化时直接使用缓存，否则重新生成
+func Get(team *model.Team) (*Poster, error) {
+	key := posterKeyPrefix + strconv.Itoa(int(team.ID))
+	if hash, err := global.Rdb.Get(global.Rctx, key).Result(); err == nil {
+		poster := newPoster(hash)
+		if _, err := os.Stat(poster.Path); err == nil {
+			// 更新修改时间，避免仍在使用的海报被定时清理删除
+			now := time.Now()
+			os.Chtimes(poster.Path, now, now)
+			return poster, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		log.Println(err)
+	}
+
+	route, ok := routeService.GetRoute(team.Route)
+	if !ok {
+		return nil, ErrRouteNotFound
+	}
+	captain, members := model.GetPersonsInTeam(int(team.ID))
+	memberNames := []string{captain.Name}
+	for _, member := range members {
+		memberNames = append(memberNames, member.Name)
+	}
+
+	// 背景模板按路线标识命名
+	data := utility.PosterData{
+		Template: route.Code,
+		Route:    route.Name,
+		TeamName: team.Name,
+		Slogan:   team.Slogan,
+		Members:  memberNames,
+	}
+	poster := newPoster(hashPoster(data))
+	if _, err := os.Stat(poster.Path); err != nil {
+		if _, err := utility.SavePoster(data, poster.Hash); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := global.Rdb.Set(global.Rctx, key, poster.Hash, posterKeyExpire).Err(); err != nil {
+		log.Println(err)
+	}
+	return poster, nil
+}
+
+// Invalidate 队伍名称、标语、路线或成员变化后删除队伍的海报缓存
+// 海报文件按内容命名，可能被其他队伍共用，已发出的链接也仍在有效期内，因此不删除文件，由 utility.CleanExpiredFiles 定时清理
+func Invalidate(teamID uint) {
+	key := posterKeyPrefix + strconv.Itoa(int(teamID))
+	if err := global.Rdb.GetDel(global.Rctx, key).Err(); err != nil && !errors.Is(err, redis.Nil) {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,143 @@
+package quotaService
+
+import (
+	"math"
+	"strconv"
+	"time"
+	"walk-server/global"
+	"walk-server/service/routeService"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 各天各路线配置的总名额，剩余名额仍存放在 day*10+route 键中
+	totalKey = "quota:total"
+	// SubmittedKey 记录每个已提交队伍占用的名额键（队伍ID -> day*10+route）
+	SubmittedKey = "quota:submitted"
+)
+
+type Quota struct {
+	Day       uint8  `json:"day"`
+	Date      string `json:"date"`
+	Route     uint8  `json:"route"`
+	RouteName string `json:"route_name"`
+	Total     int64  `json:"total"`
+	Remaining int64  `json:"remaining"`
+	Used      int64  `json:"used"`
+}
+
+// Key 获取某天某路线剩余名额的键，沿用 day*10+route 的格式，因此路线编号需小于 10
+func Key(day uint8, route uint8) string {
+	return strconv.Itoa(int(day)*10 + int(route))
+}
+
+// ParseKey 解析剩余名额的键
+func ParseKey(key string) (day uint8, route uint8, err error) {
+	n, err := strconv.Atoi(key)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint8(n / 10), uint8(n % 10), nil
+}
+
+// StartDay 报名第一天的零点
+func StartDay() time.Time {
+	startTime, _ := time.ParseInLocation(
+		time.DateTime,
+		global.Config.GetString("startDate"),
+		time.Local,
+	)
+	return time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, startTime.Location())
+}
+
+// Days 根据 startDate 和 expiredDate 计算报名的天数
+func Days() uint8 {
+	expiredTime, _ := time.ParseInLocation(
+		time.DateTime,
+		global.Config.GetString("expiredDate"),
+		time.Local,
+	)
+	days := math.Ceil(expiredTime.Sub(StartDay()).Hours() / 24)
+	if days < 1 {
+		return 1
+	}
+	return uint8(days)
+}
+
+// GetQuotas 获取每天各路线的名额情况
+func GetQuotas() ([]Quota, error) {
+	pipe := global.Rdb.Pipeline()
+	build := queueQuotas(pipe)
+	if _, err := pipe.Exec(global.Rctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	return build(), nil
+}
+
+// Snapshot 同一时刻的名额、提交队伍及其占用的名额键
+type Snapshot struct {
+	Quotas    []Quota
+	Teams     []string          // teams 集合中的队伍ID
+	Submitted map[string]string // SubmittedKey 中的 队伍ID -> 名额键
+}
+
+// GetSnapshot 在一个事务中读取名额、teams 集合和 SubmittedKey，期间的提交和撤回不会只影响其中一部分
+func GetSnapshot() (*Snapshot, error) {
+	pipe := global.Rdb.TxPipeline()
+	teamsCmd := pipe.SMembers(global.Rctx, "teams")
+	submittedCmd := pipe.HGetAll(global.Rctx, SubmittedKey)
+	build := queueQuotas(pipe)
+	if _, err := pipe.Exec(global.Rctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if err := teamsCmd.Err(); err != nil {
+		return nil, err
+	}
+	if err := submittedCmd.Err(); err != nil {
+		return nil, err
+	}
+	return &Snapshot{
+		Quotas:    build(),
+		Teams:     teamsCmd.Val(),
+		Submitted: submittedCmd.Val(),
+	}, nil
+}
+
+// queueQuotas 将读取名额的命令加入 pipe，返回的函数在执行 pipe 后生成结果
+func queueQuotas(pipe redis.Pipeliner) func() []Quota {
+	routes := routeService.GetRoutes()
+	days := Days()
+
+	remainingCmds := make([]*redis.StringCmd, 0, int(days)*len(routes))
+	totalCmds := make([]*redis.StringCmd, 0, int(days)*len(routes))
+	for day := uint8(0); day < days; day++ {
+		for _, route := range routes {
+			key := Key(day, route.ID)
+			remainingCmds = append(remainingCmds, pipe.Get(global.Rctx, key))
+			totalCmds = append(totalCmds, pipe.HGet(global.Rctx, totalKey, key))
+		}
+	}
+
+	return func() []Quota {
+		quotas := make([]Quota, 0, len(remainingCmds))
+		i := 0
+		for day := uint8(0); day < days; day++ {
+			for _, route := range routes {
+				remaining, _ := remainingCmds[i].Int64()
+				total, _ := totalCmds[i].Int64()
+				quotas = append(quotas, Quota{
+					Day:       day,
+					Date:      StartDay().AddDate(0, 0, int(day)).Format(time.DateOnly),
+					Route:     route.ID,
+					RouteName: route.Name,
+					Total:     total,
+					Remaining: remaining,
+					Used:      total - remaining,
+				})
+				i++
+			}
+		}
+		return quotas
+	}
+}
@@ -0,0 +1,128 @@
+package quotaService
+
+import (
+	"errors"
+	"strconv"
+	"walk-server/global"
+	"walk-server/service/routeService"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrQuotaNotEnough = errors.New("已使用的名额超过调整后的数量")
+
+// 原子地调整名额，set 模式设置总名额，add 模式在总名额和剩余名额上同时增减
+// 返回 {是否成功, 原总名额, 原剩余名额, 新总名额, 新剩余名额}
+var adjust = redis.NewScript(`
+local key = KEYS[1];
+local totalKey = KEYS[2];
+local mode = ARGV[1];
+local value = tonumber(ARGV[2]);
+
+local remaining = tonumber(redis.call("get", key) or "0");
+local total = tonumber(redis.call("hget", totalKey, key) or tostring(remaining));
+local used = total - remaining;
+
+local newTotal = total + value;
+local newRemaining = remaining + value;
+if mode == "set" then
+	newTotal = value;
+	newRemaining = value - used;
+end
+
+if newRemaining < 0 then
+	return {0, total, remaining, total, remaining};
+end
+
+redis.call("set", key, newRemaining);
+redis.call("hset", totalKey, key, newTotal);
+return {1, total, remaining, newTotal, newRemaining};
+`)
+
+// Change 一次名额调整的结果
+type Change struct {
+	PrevTotal     int64
+	PrevRemaining int64
+	Total         int64
+	Remaining     int64
+}
+
+// Init 初始化每天各路线的名额，已存在的名额不会被覆盖
+func Init() {
+	for day := uint8(0); day < Days(); day++ {
+		for _, route := range routeService.GetRoutes() {
+			key := Key(day, route.ID)
+			value := global.Config.GetInt64("teamUpperLimit" + "." + strconv.Itoa(int(day)) + "." + strconv.Itoa(int(route.ID)))
+			remaining, err := global.Rdb.Get(global.Rctx, key).Int64()
+			if err == redis.Nil {
+				global.Rdb.Set(global.Rctx, key, value, 0)
+				global.Rdb.HSet(global.Rctx, totalKey, key, value)
+			} else if err == nil {
+				// 旧数据没有记录总名额时，以当前剩余名额作为总名额
+				global.Rdb.HSetNX(global.Rctx, totalKey, key, remaining)
+			}
+		}
+	}
+}
+
+// SetTotal 设置某天某路线的总名额，剩余名额随之变化
+func SetTotal(day uint8, route uint8, total int64) (*Change, error) {
+	return run(day, route, "set", total)
+}
+
+// Add 为某天某路线增加（或减少）名额
+func Add(day uint8, route uint8, num int64) (*Change, error) {
+	return run(day, route, "add", num)
+}
+
+func run(day uint8, route uint8, mode string, value int64) (*Change, error) {
+	result, err := adjust.Run(global.Rctx, global.Rdb, []string{Key(day, route), totalKey}, mode, value).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	change := &Change{
+		PrevTotal:     result[1],
+		PrevRemaining: result[2],
+		Total:         result[3],
+		Remaining:     result[4],
+	}
+	if result[0] == 0 {
+		return change, ErrQuotaNotEnough
+	}
+	return change, nil
+}
+
+// 按 SubmittedKey 中占用该名额键的队伍数量重新计算剩余名额，计数与写入在同一个脚本中，不会与提交和撤回交错
+// 没有记录总名额时不做修改，返回 {原剩余名额, 新剩余名额}
+var recount = redis.NewScript(`
+local key = KEYS[1];
+local totalKey = KEYS[2];
+local submittedKey = KEYS[3];
+
+local total = redis.call("hget", totalKey, key);
+local remaining = tonumber(redis.call("get", key) or "0");
+if not total then
+	return {remaining, remaining};
+end
+
+local used = 0;
+for _, value in ipairs(redis.call("hvals", submittedKey)) do
+	if value == key then
+		used = used + 1;
+	end
+end
+
+local newRemaining = tonumber(total) - used;
+redis.call("set", key, newRemaining);
+return {remaining, newRemaining};
+`)
+
+// Recount 按实际提交的队伍数量修正某天某路线的剩余名额，用于对账后消除计数偏差
+func Recount(day uint8, route uint8) (*Change, error) {
+	result, err := recount.Run(global.Rctx, global.Rdb, []string{Key(day, route), totalKey, SubmittedKey}).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	return &Change{PrevRemaining: result[0], Remaining: result[1]}, nil
+}
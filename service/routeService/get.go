@@ -0,0 +1,130 @@
+package routeService
+
+import (
+	"sort"
+	"sync"
+	"walk-server/constant"
+	"walk-server/global"
+	"walk-server/model"
+
+	"gorm.io/gorm"
+)
+
+// 路线目录读多写少，缓存在内存中，修改后重新加载
+var (
+	mu     sync.RWMutex
+	routes []model.Route
+)
+
+// Load 从数据库加载路线目录，数据库中没有路线时写入默认路线
+func Load() error {
+	var count int64
+	if err := global.DB.Model(&model.Route{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		for _, r := range constant.DefaultRoutes {
+			route := model.Route{ID: r.ID, Code: r.Code, Name: r.Name, Area: r.Area}
+			for i, name := range r.Points {
+				route.Points = append(route.Points, model.RoutePoint{Point: int8(i), Name: name})
+			}
+			if err := global.DB.Create(&route).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	var result []model.Route
+	err := global.DB.Preload("Points", func(db *gorm.DB) *gorm.DB {
+		return db.Order("point")
+	}).Order("id").Find(&result).Error
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	routes = result
+	mu.Unlock()
+	return nil
+}
+
+// GetRoutes 获取全部路线（按 ID 排序）
+func GetRoutes() []model.Route {
+	mu.RLock()
+	defer mu.RUnlock()
+	return routes
+}
+
+// GetRoute 根据 ID 获取路线
+func GetRoute(id uint8) (*model.Route, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	i := sort.Search(len(routes), func(i int) bool { return routes[i].ID >= id })
+	if i < len(routes) && routes[i].ID == id {
+		return &routes[i], true
+	}
+	return nil, false
+}
+
+// Exists 路线是否存在
+func Exists(id uint8) bool {
+	_, ok := GetRoute(id)
+	return ok
+}
+
+// GetRouteName 获取路线名称
+func GetRouteName(id uint8) string {
+	route, ok := GetRoute(id)
+	if !ok {
+		return "未知路线"
+	}
+	return route.Name
+}
+
+// GetPointName 用于根据 Route 和 Point 返回对应的点位名称
+func GetPointName(route uint8, point int8) string {
+	r, ok := GetRoute(route)
+	if !ok || point < 0 || int(point) >= len(r.Points) {
+		return "未知点位"
+	}
+	return r.Points[point].Name
+}
+
+// GetEndPoint 获取路线终点的点位序号
+func GetEndPoint(route uint8) int8 {
+	r, ok := GetRoute(route)
+	if !ok || len(r.Points) == 0 {
+		return 0
+	}
+	return r.Points[len(r.Points)-1].Point
+}
+
+// IsSameArea 两条路线是否属于同一片区
+func IsSameArea(a, b uint8) bool {
+	if a == b {
+		return true
+	}
+	ra, ok1 := GetRoute(a)
+	rb, ok2 := GetRoute(b)
+	return ok1 && ok2 && ra.Area != 0 && ra.Area == rb.Area
+}
+
+// MapPoint 将路线 from 的点位映射到同一片区的路线 to 上名称相同的点位，用于共用点位的扫码
+// to 上没有同名点位时 ok 为 false，说明队伍走到了其他路线的点位
+func MapPoint(from uint8, point int8, to uint8) (int8, bool) {
+	if from == to {
+		return point, true
+	}
+	rf, ok1 := GetRoute(from)
+	rt, ok2 := GetRoute(to)
+	if !ok1 || !ok2 || point < 0 || int(point) >= len(rf.Points) {
+		return 0, false
+	}
+	name := rf.Points[point].Name
+	for _, p := range rt.Points {
+		if p.Name == name {
+			return p.Point, true
+		}
+	}
+	return 0, false
+}
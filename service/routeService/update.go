@@ -0,0 +1,45 @@
+package routeService
+
+import (
+	"walk-server/global"
+	"walk-server/model"
+
+	"gorm.io/gorm"
+)
+
+// Save 新建或覆盖一条路线及其全部点位，点位序号按传入顺序重新编排
+func Save(route *model.Route) error {
+	for i := range route.Points {
+		route.Points[i].ID = 0
+		route.Points[i].RouteID = route.ID
+		route.Points[i].Point = int8(i)
+	}
+
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("route_id = ?", route.ID).Delete(&model.RoutePoint{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Points").Save(route).Error; err != nil {
+			return err
+		}
+		return tx.Create(&route.Points).Error
+	})
+	if err != nil {
+		return err
+	}
+	return Load()
+}
+
+// Delete 删除一条路线及其点位
+func Delete(id uint8) error {
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("route_id = ?", id).Delete(&model.RoutePoint{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Route{}, id).Error
+	})
+	if err != nil {
+		return err
+	}
+	return Load()
+}
@@ -0,0 +1,87 @@
+package teamService
+
+import (
+	"errors"
+	"walk-server/global"
+	"walk-server/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNoCheckpoint       = errors.New("no checkpoint to revert")
+	ErrCheckpointOutdated = errors.New("checkpoint is not the latest transition")
+	ErrTeamChanged        = errors.New("team state changed after checkpoint")
+	ErrMemberChanged      = errors.New("member walk status changed after checkpoint")
+	ErrCheckpointLegacy   = errors.New("checkpoint has no snapshot to revert")
+)
+
+// RevertCheckpoint 撤销队伍最后一次打卡，将队伍和成员恢复到打卡前的状态
+// checkpointID 必须是队伍最后一条未撤销的记录，且打卡后队伍和成员状态没有再发生变化
+func RevertCheckpoint(teamID uint, checkpointID uint, adminID uint, reason string) (*model.Team, *model.Checkpoint, error) {
+	var team model.Team
+	var checkpoint *model.Checkpoint
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&team, teamID).Error; err != nil {
+			return err
+		}
+
+		var err error
+		checkpoint, err = model.TxGetLastCheckpoint(tx, teamID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoCheckpoint
+		} else if err != nil {
+			return err
+		}
+		if checkpoint.ID != checkpointID {
+			return ErrCheckpointOutdated
+		}
+		if checkpoint.PrevTime.IsZero() { // 旧版本的记录没有保存打卡前的状态
+			return ErrCheckpointLegacy
+		}
+		if team.Point != checkpoint.Point || team.Status != checkpoint.Status {
+			return ErrTeamChanged
+		}
+
+		var persons []model.Person
+		if err := tx.Where("team_id = ?", teamID).Find(&persons).Error; err != nil {
+			return err
+		}
+		personMap := make(map[string]*model.Person, len(persons))
+		for i := range persons {
+			personMap[persons[i].OpenId] = &persons[i]
+		}
+		for _, member := range checkpoint.Members {
+			person, ok := personMap[member.OpenID]
+			if !ok || person.WalkStatus != member.Status {
+				return ErrMemberChanged
+			}
+		}
+
+		for _, member := range checkpoint.Members {
+			person := personMap[member.OpenID]
+			person.WalkStatus = member.PrevStatus
+			if err := model.TxUpdatePerson(tx, person); err != nil {
+				return err
+			}
+		}
+
+		team.Point = checkpoint.PrevPoint
+		team.Status = checkpoint.PrevStatus
+		team.Time = checkpoint.PrevTime
+		team.IsLost = checkpoint.PrevLost
+		team.LostReason = checkpoint.PrevLostReason
+		team.Code = checkpoint.PrevCode
+		team.StartNum = checkpoint.PrevStartNum
+		if err := tx.Save(&team).Error; err != nil {
+			return err
+		}
+
+		return model.TxRevertCheckpoint(tx, checkpoint, adminID, reason)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &team, checkpoint, nil
+}
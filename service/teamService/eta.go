@@ -0,0 +1,171 @@
+package teamService
+
+import (
+	"sort"
+	"time"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/service/routeService"
+)
+
+const (
+	EstimateObserved   = "observed"   // 根据本次活动的扫码记录估计
+	EstimateConfigured = "configured" // 使用路线管理中设置的预计用时
+)
+
+// SegmentEstimate 从上一点位走到某个点位的预计用时
+type SegmentEstimate struct {
+	Point    int8          `json:"point"`   // 到达的点位
+	Duration time.Duration `json:"-"`       // 预计用时
+	Minutes  float64       `json:"minutes"` // 预计用时的分钟数
+	Samples  int           `json:"samples"` // 参与估计的扫码记录数量
+	Source   string        `json:"source"`  // 估计来源
+}
+
+// Arrival 预计到达某个点位的队伍
+type Arrival struct {
+	TeamID  uint      `json:"team_id"`
+	Name    string    `json:"name"`
+	Num     uint      `json:"num"`  // 仍在行进的人数
+	From    int8      `json:"from"` // 出发的点位
+	Depart  time.Time `json:"depart"`
+	ETA     time.Time `json:"eta"`
+	Overdue bool      `json:"overdue"` // 已超过预计到达时间
+	IsLost  bool      `json:"is_lost"`
+}
+
+// PointArrivals 某个点位的预计到达队伍，按预计到达时间排序
+type PointArrivals struct {
+	Route    uint8            `json:"route"`
+	Point    int8             `json:"point"`
+	Name     string           `json:"name"`
+	Estimate *SegmentEstimate `json:"estimate"` // 没有扫码记录也没有设置预计用时时为空
+	Arrivals []Arrival        `json:"arrivals"`
+}
+
+// estimateMinSamples 扫码记录少于该数量时优先使用路线管理中设置的预计用时
+func estimateMinSamples() int {
+	if n := global.Config.GetInt("eta.minSamples"); n > 0 {
+		return n
+	}
+	return 5
+}
+
+// EstimateSegments 估计路线各段的用时，返回以到达点位为键的估计值
+// 取本次活动中相邻两次扫码间隔的中位数，记录不足时使用路线管理中设置的预计用时
+func EstimateSegments(route uint8, checkpoints []model.Checkpoint) map[int8]SegmentEstimate {
+	// checkpoints 需要按队伍和记录顺序排列
+	samples := make(map[int8][]time.Duration)
+	for i := 1; i < len(checkpoints); i++ {
+		prev, cur := checkpoints[i-1], checkpoints[i]
+		if prev.TeamID != cur.TeamID || cur.Status != 2 {
+			continue
+		}
+		if cur.PrevPoint != prev.Point || cur.Point != prev.Point+1 {
+			continue
+		}
+		if d := cur.Time.Sub(prev.Time); d > 0 {
+			samples[cur.Point] = append(samples[cur.Point], d)
+		}
+	}
+
+	r, ok := routeService.GetRoute(route)
+	if !ok {
+		return nil
+	}
+	minSamples := estimateMinSamples()
+	estimates := make(map[int8]SegmentEstimate, len(r.Points))
+	for _, p := range r.Points[1:] {
+		observed := samples[p.Point]
+		estimate := SegmentEstimate{Point: p.Point, Samples: len(observed)}
+		switch {
+		case len(observed) >= minSamples || (len(observed) > 0 && p.Duration == 0):
+			estimate.Duration = median(observed)
+			estimate.Source = EstimateObserved
+		case p.Duration > 0:
+			estimate.Duration = time.Duration(p.Duration) * time.Minute
+			estimate.Source = EstimateConfigured
+		default:
+			continue
+		}
+		estimate.Minutes = estimate.Duration.Minutes()
+		estimates[p.Point] = estimate
+	}
+	return estimates
+}
+
+// PredictArrivals 预测路线上行进中的队伍到达下一个点位的时间
+func PredictArrivals(route uint8) ([]PointArrivals, error) {
+	r, ok := routeService.GetRoute(route)
+	if !ok {
+		return nil, nil
+	}
+
+	var checkpoints []model.Checkpoint
+	err := global.DB.Where("route = ? AND reverted = ?", route, false).Order("team_id, id").Find(&checkpoints).Error
+	if err != nil {
+		return nil, err
+	}
+	estimates := EstimateSegments(route, checkpoints)
+
+	// 队伍最后一次扫码时仍在行进的人数
+	walking := make(map[uint]uint)
+	for _, checkpoint := range checkpoints {
+		walking[checkpoint.TeamID] = checkpoint.Num
+	}
+
+	// 起点扫码成功和行进中的队伍
+	var teams []model.Team
+	if err := global.DB.Where("route = ? AND status IN ?", route, []uint8{2, 5}).Find(&teams).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]PointArrivals, 0, len(r.Points)-1)
+	index := make(map[int8]int, len(r.Points)-1)
+	for _, p := range r.Points[1:] {
+		pointArrivals := PointArrivals{Route: route, Point: p.Point, Name: p.Name, Arrivals: []Arrival{}}
+		if estimate, ok := estimates[p.Point]; ok {
+			pointArrivals.Estimate = &estimate
+		}
+		index[p.Point] = len(result)
+		result = append(result, pointArrivals)
+	}
+
+	now := time.Now()
+	for _, team := range teams {
+		i, ok := index[team.Point+1]
+		if !ok || result[i].Estimate == nil {
+			continue
+		}
+		eta := team.Time.Add(result[i].Estimate.Duration)
+		num, ok := walking[team.ID]
+		if !ok {
+			num = team.StartNum
+		}
+		result[i].Arrivals = append(result[i].Arrivals, Arrival{
+			TeamID:  team.ID,
+			Name:    team.Name,
+			Num:     num,
+			From:    team.Point,
+			Depart:  team.Time,
+			ETA:     eta,
+			Overdue: eta.Before(now),
+			IsLost:  team.IsLost,
+		})
+	}
+	for i := range result {
+		arrivals := result[i].Arrivals
+		sort.Slice(arrivals, func(a, b int) bool { return arrivals[a].ETA.Before(arrivals[b].ETA) })
+	}
+	return result, nil
+}
+
+func median(durations []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
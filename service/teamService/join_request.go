@@ -0,0 +1,61 @@
+package teamService
+
+import (
+	"log"
+	"time"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/utility"
+)
+
+// JoinRequestTimeout 加入申请的有效时间，由 team.joinRequestTimeout 配置（分钟），默认 30 分钟
+func JoinRequestTimeout() time.Duration {
+	timeout := global.Config.GetInt("team.joinRequestTimeout")
+	if timeout <= 0 {
+		timeout = 30
+	}
+	return time.Duration(timeout) * time.Minute
+}
+
+// IsJoinRequestExpired 申请是否已超过有效时间
+func IsJoinRequestExpired(request *model.JoinRequest) bool {
+	return time.Since(request.CreatedAt) > JoinRequestTimeout()
+}
+
+// ExpireJoinRequests 将超时未处理的申请标记为过期并通知申请人
+func ExpireJoinRequests() {
+	requests, err := model.GetJoinRequestsBefore(time.Now().Add(-JoinRequestTimeout()))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, request := range requests {
+		ok, err := model.HandleJoinRequest(request.ID, model.JoinRequestExpired)
+		if err != nil {
+			log.Println(err)
+			continue
+		} else if !ok {
+			continue
+		}
+
+		person, err := model.GetPerson(request.OpenId)
+		if err != nil {
+			continue
+		}
+		team, err := GetTeamByID(request.TeamID)
+		if err != nil {
+			continue
+		}
+		utility.SendMessage("你加入"+team.Name+"的申请已过期", nil, person)
+	}
+}
+
+// RunExpireJoinRequests 每分钟清理一次过期的加入申请
+func RunExpireJoinRequests() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		ExpireJoinRequests()
+	}
+}
@@ -0,0 +1,130 @@
+package teamService
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/service/routeService"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 管理员手动取消失联标记后，在队伍到达下一个点位之前不再自动标记
+const lostIgnoredKeyPrefix = "lost:ignored:"
+
+// ExpectedDuration 获取队伍从当前点位走到下一个点位的预计用时和下一个点位的名称
+// 点位未设置预计用时时使用 lost.defaultDuration，均未设置时返回 false
+func ExpectedDuration(team *model.Team) (time.Duration, string, bool) {
+	route, ok := routeService.GetRoute(team.Route)
+	if !ok {
+		return 0, "", false
+	}
+	next := int(team.Point) + 1
+	if team.Point < 0 || next >= len(route.Points) {
+		return 0, "", false
+	}
+
+	minutes := int(route.Points[next].Duration)
+	if minutes == 0 {
+		minutes = global.Config.GetInt("lost.defaultDuration")
+	}
+	if minutes <= 0 {
+		return 0, "", false
+	}
+	return time.Duration(minutes) * time.Minute, route.Points[next].Name, true
+}
+
+// SetLost 手动设置队伍的失联状态，取消标记时在队伍下次扫码前不再自动标记
+func SetLost(team *model.Team, lost bool, reason string) error {
+	team.IsLost = lost
+	if lost {
+		team.LostReason = reason
+	} else {
+		team.LostReason = ""
+	}
+	if err := global.DB.Model(team).Updates(map[string]any{
+		"is_lost":     team.IsLost,
+		"lost_reason": team.LostReason,
+	}).Error; err != nil {
+		return err
+	}
+
+	key := lostIgnoredKeyPrefix + strconv.Itoa(int(team.ID))
+	if lost {
+		return global.Rdb.Del(global.Rctx, key).Err()
+	}
+	return global.Rdb.Set(global.Rctx, key, team.Point, 24*time.Hour).Err()
+}
+
+// isLostIgnored 管理员是否已在当前点位取消过该队伍的失联标记
+func isLostIgnored(team *model.Team) bool {
+	point, err := global.Rdb.Get(global.Rctx, lostIgnoredKeyPrefix+strconv.Itoa(int(team.ID))).Int()
+	if errors.Is(err, redis.Nil) {
+		return false
+	} else if err != nil {
+		log.Println(err)
+		return false
+	}
+	return point == int(team.Point)
+}
+
+// DetectLostTeams 将超过预计用时仍未到达下一个点位的队伍标记为失联，返回新标记的队伍ID
+func DetectLostTeams() []uint {
+	grace := time.Duration(global.Config.GetInt("lost.grace")) * time.Minute
+
+	// 起点扫码成功和行进中的队伍
+	var teams []model.Team
+	if err := global.DB.Where("is_lost = ? AND status IN ?", false, []uint8{2, 5}).Find(&teams).Error; err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	var flagged []uint
+	now := time.Now()
+	for i := range teams {
+		team := &teams[i]
+		expected, next, ok := ExpectedDuration(team)
+		if !ok {
+			continue
+		}
+		elapsed := now.Sub(team.Time)
+		if elapsed <= expected+grace || isLostIgnored(team) {
+			continue
+		}
+
+		reason := fmt.Sprintf("超过预计时间未到达%s（预计%d分钟，已用时%d分钟）",
+			next, int(expected.Minutes()), int(elapsed.Minutes()))
+		// 只在检测期间队伍没有扫码时标记
+		result := global.DB.Model(&model.Team{}).
+			Where("id = ? AND is_lost = ? AND point = ? AND time = ?", team.ID, false, team.Point, team.Time).
+			Updates(map[string]any{"is_lost": true, "lost_reason": reason})
+		if result.Error != nil {
+			log.Println(result.Error)
+			continue
+		}
+		if result.RowsAffected > 0 {
+			flagged = append(flagged, team.ID)
+		}
+	}
+	return flagged
+}
+
+// RunDetectLostTeams 按配置定时检测失联队伍，lost.interval 为间隔分钟数（0 为关闭）
+func RunDetectLostTeams() {
+	interval := global.Config.GetInt("lost.interval")
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		if flagged := DetectLostTeams(); len(flagged) > 0 {
+			log.Printf("自动标记失联队伍 %d 支: %v\n", len(flagged), flagged)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package teamService
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/service/quotaService"
+	"walk-server/service/routeService"
+)
+
+const (
+	DirectionMysql = "mysql" // 以 Redis 为准修正 MySQL
+	DirectionRedis = "redis" // 以 MySQL 为准修正 Redis
+)
+
+var ErrInvalidDirection = errors.New("invalid direction")
+
+// QuotaDiff 某天某路线剩余名额与实际提交数量不一致
+type QuotaDiff struct {
+	Day       uint8 `json:"day"`
+	Route     uint8 `json:"route"`
+	Total     int64 `json:"total"`
+	Remaining int64 `json:"remaining"`
+	Submitted int64 `json:"submitted"` // 在该天该路线提交的队伍数量
+	Expected  int64 `json:"expected"`  // 按提交数量计算应剩余的名额
+}
+
+// ReconcileReport Redis 与 MySQL 提交状态的差异
+type ReconcileReport struct {
+	RedisOnly []uint      `json:"redis_only"` // 仅在 Redis 中提交
+	MysqlOnly []uint      `json:"mysql_only"` // 仅在 MySQL 中提交
+	Deleted   []string    `json:"deleted"`    // 在 Redis 中提交但队伍已不存在
+	Quotas    []QuotaDiff `json:"quotas"`
+	Unknown   int         `json:"unknown"`   // 没有名额记录的提交队伍（管理员提交或旧数据），不计入名额
+	Unfixable []uint      `json:"unfixable"` // 以 MySQL 为准修正时无法写入 Redis 的队伍（不知道提交时占用哪一天的名额），需要人工处理
+	Time      time.Time   `json:"time"`
+}
+
+// Diff 比较 Redis 和 MySQL 的提交状态，不做任何修改
+func Diff() (*ReconcileReport, error) {
+	report := &ReconcileReport{
+		RedisOnly: []uint{},
+		MysqlOnly: []uint{},
+		Deleted:   []string{},
+		Quotas:    []QuotaDiff{},
+		Unfixable: []uint{},
+		Time:      time.Now(),
+	}
+
+	// teams 集合、名额记录和剩余名额必须在同一时刻读取，否则期间的提交会被算作名额偏差
+	snapshot, err := quotaService.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	redisTeams, submittedKeys := snapshot.Teams, snapshot.Submitted
+
+	var teams []model.Team
+	if err := global.DB.Select("id", "submit").Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	mysqlTeams := make(map[string]bool, len(teams))
+	for _, team := range teams {
+		mysqlTeams[strconv.Itoa(int(team.ID))] = team.Submit
+	}
+
+	inRedis := make(map[string]bool, len(redisTeams))
+	submittedCount := make(map[string]int64)
+	for _, teamID := range redisTeams {
+		inRedis[teamID] = true
+		submit, exists := mysqlTeams[teamID]
+		if !exists {
+			report.Deleted = append(report.Deleted, teamID)
+			continue
+		}
+		if !submit {
+			id, _ := strconv.Atoi(teamID)
+			report.RedisOnly = append(report.RedisOnly, uint(id))
+		}
+		if key, ok := submittedKeys[teamID]; ok {
+			submittedCount[key]++
+		} else {
+			report.Unknown++
+		}
+	}
+	for _, team := range teams {
+		if team.Submit && !inRedis[strconv.Itoa(int(team.ID))] {
+			report.MysqlOnly = append(report.MysqlOnly, team.ID)
+		}
+	}
+
+	for _, quota := range snapshot.Quotas {
+		submitted := submittedCount[quotaService.Key(quota.Day, quota.Route)]
+		if quota.Total-submitted != quota.Remaining {
+			report.Quotas = append(report.Quotas, QuotaDiff{
+				Day:       quota.Day,
+				Route:     quota.Route,
+				Total:     quota.Total,
+				Remaining: quota.Remaining,
+				Submitted: submitted,
+				Expected:  quota.Total - submitted,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// Reconcile 按指定方向修正提交状态，随后按实际提交数量修正剩余名额，返回修正前的差异
+// 以 MySQL 为准时，仅在 MySQL 中提交的队伍无法确定占用的名额，只记录在 Unfixable 中
+func Reconcile(direction string) (*ReconcileReport, error) {
+	if direction != DirectionMysql && direction != DirectionRedis {
+		return nil, ErrInvalidDirection
+	}
+
+	report, err := Diff()
+	if err != nil {
+		return nil, err
+	}
+
+	// 已删除的队伍两个方向都直接从 Redis 中移除
+	for _, teamID := range report.Deleted {
+		global.Rdb.SRem(global.Rctx, "teams", teamID)
+		global.Rdb.HDel(global.Rctx, quotaService.SubmittedKey, teamID)
+	}
+
+	if direction == DirectionMysql {
+		if len(report.RedisOnly) > 0 {
+			if err := global.DB.Model(&model.Team{}).Where("id IN ?", report.RedisOnly).Update("submit", true).Error; err != nil {
+				return nil, err
+			}
+		}
+		if len(report.MysqlOnly) > 0 {
+			if err := global.DB.Model(&model.Team{}).Where("id IN ?", report.MysqlOnly).Update("submit", false).Error; err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for _, id := range report.RedisOnly {
+			teamID := strconv.Itoa(int(id))
+			global.Rdb.SRem(global.Rctx, "teams", teamID)
+			global.Rdb.HDel(global.Rctx, quotaService.SubmittedKey, teamID)
+		}
+		report.Unfixable = append(report.Unfixable, report.MysqlOnly...)
+	}
+
+	// 提交状态修正后按名额记录重新计算每个名额键（移除的队伍会退回名额），
+	// 计数和写入在同一个脚本中完成，不会覆盖期间的提交和撤回
+	for day := uint8(0); day < quotaService.Days(); day++ {
+		for _, route := range routeService.GetRoutes() {
+			if _, err := quotaService.Recount(day, route.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// RunReconcile 按配置定时对账，reconcile.interval 为间隔分钟数（0 为关闭），
+// reconcile.direction 为空时只记录差异不做修正
+func RunReconcile() {
+	interval := global.Config.GetInt("reconcile.interval")
+	if interval <= 0 {
+		return
+	}
+	direction := global.Config.GetString("reconcile.direction")
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		var report *ReconcileReport
+		var err error
+		if direction == "" {
+			report, err = Diff()
+		} else {
+			report, err = Reconcile(direction)
+		}
+		if err != nil {
+			log.Println("对账失败:", err)
+			continue
+		}
+		if len(report.RedisOnly)+len(report.MysqlOnly)+len(report.Deleted)+len(report.Quotas) > 0 {
+			log.Printf("对账差异: 仅Redis %d, 仅MySQL %d, 已删除 %d, 名额不一致 %d\n",
+				len(report.RedisOnly), len(report.MysqlOnly), len(report.Deleted), len(report.Quotas))
+		}
+		if len(report.Unfixable) > 0 {
+			log.Println("对账无法修正的队伍（仅在 MySQL 中提交）:", report.Unfixable)
+		}
+	}
+}
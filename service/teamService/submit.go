@@ -0,0 +1,55 @@
+package teamService
+
+import (
+	"log"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/service/quotaService"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Submit 的返回值
+const (
+	SubmitSuccess   = 0 // 提交成功
+	SubmitDuplicate = 1 // 队伍已提交
+	SubmitFull      = 2 // 名额已满
+)
+
+// 编写Lua脚本 - 先判断是否已经提交，再判断是否达到上限、提交后将剩余数量减一并记录提交的团队id及占用的名额
+var submit = redis.NewScript(`
+local teamID = KEYS[1];
+local dailyRouteKey = KEYS[2];
+local submittedKey = KEYS[3];
+
+if redis.call("SIsMember", "teams", teamID) == 1 then
+	return 1;
+end
+
+local num = redis.call("get", dailyRouteKey);
+if not num or tonumber(num) <= 0 then
+	return 2;
+end
+
+redis.call("SAdd", "teams", teamID);
+redis.call("decr", dailyRouteKey);
+redis.call("hset", submittedKey, teamID, dailyRouteKey);
+return 0;
+`)
+
+// Submit 使用 dailyRouteKey 对应的名额提交队伍，提交成功后同步 MySQL 中的提交状态
+// 名额已经在 Redis 中扣除，MySQL 写入失败时只记录日志，由定时对账发现差异
+func Submit(teamID string, dailyRouteKey string) (int64, error) {
+	n, err := submit.Run(global.Rctx, global.Rdb, []string{teamID, dailyRouteKey, quotaService.SubmittedKey}).Int64()
+	if err == nil && n == SubmitSuccess {
+		if err := SetSubmitted(teamID, true); err != nil {
+			log.Println(err)
+		}
+	}
+	return n, err
+}
+
+// SetSubmitted 更新 MySQL 中队伍的提交状态，与 Redis 中的 teams 集合保持一致
+func SetSubmitted(teamID string, submitted bool) error {
+	return global.DB.Model(&model.Team{}).Where("id = ?", teamID).Update("submit", submitted).Error
+}
@@ -0,0 +1,130 @@
+package teamService
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/service/policyService"
+	"walk-server/service/quotaService"
+	"walk-server/utility"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	waitlistPrefix = "waitlist:"      // 每天各路线的候补队列（有序集合，分数为加入时间）
+	waitlistTeams  = "waitlist:teams" // 队伍ID -> 所在候补队列的名额键
+)
+
+var errNotEligible = errors.New("team not eligible")
+
+func waitlistKey(dailyRouteKey string) string {
+	return waitlistPrefix + dailyRouteKey
+}
+
+// JoinWaitlist 将队伍加入 dailyRouteKey 对应的候补队列，已在队列中时保持原来的位置，返回排在第几位
+func JoinWaitlist(teamID string, dailyRouteKey string) (int64, error) {
+	// 换了路线或日期后重新排队
+	if old, err := global.Rdb.HGet(global.Rctx, waitlistTeams, teamID).Result(); err == nil && old != dailyRouteKey {
+		global.Rdb.ZRem(global.Rctx, waitlistKey(old), teamID)
+	}
+
+	pipe := global.Rdb.TxPipeline()
+	pipe.ZAddNX(global.Rctx, waitlistKey(dailyRouteKey), redis.Z{Score: float64(time.Now().UnixNano()), Member: teamID})
+	pipe.HSet(global.Rctx, waitlistTeams, teamID, dailyRouteKey)
+	rank := pipe.ZRank(global.Rctx, waitlistKey(dailyRouteKey), teamID)
+	if _, err := pipe.Exec(global.Rctx); err != nil {
+		return 0, err
+	}
+	return rank.Val() + 1, nil
+}
+
+// GetWaitlistPosition 获取队伍在候补队列中的位置，不在队列中时 ok 为 false
+func GetWaitlistPosition(teamID string) (dailyRouteKey string, position int64, ok bool) {
+	dailyRouteKey, err := global.Rdb.HGet(global.Rctx, waitlistTeams, teamID).Result()
+	if err != nil {
+		return "", 0, false
+	}
+	rank, err := global.Rdb.ZRank(global.Rctx, waitlistKey(dailyRouteKey), teamID).Result()
+	if err != nil {
+		return "", 0, false
+	}
+	return dailyRouteKey, rank + 1, true
+}
+
+// LeaveWaitlist 将队伍移出候补队列
+func LeaveWaitlist(teamID string) {
+	dailyRouteKey, err := global.Rdb.HGet(global.Rctx, waitlistTeams, teamID).Result()
+	if err != nil {
+		return
+	}
+	global.Rdb.ZRem(global.Rctx, waitlistKey(dailyRouteKey), teamID)
+	global.Rdb.HDel(global.Rctx, waitlistTeams, teamID)
+}
+
+// PromoteWaitlist 名额增加后，按顺序为 dailyRouteKey 候补队列中符合条件的队伍提交，直到名额用完
+// 不再符合提交条件的队伍会被移出队列，返回提交成功的队伍ID
+func PromoteWaitlist(dailyRouteKey string) []uint {
+	_, route, err := quotaService.ParseKey(dailyRouteKey)
+	if err != nil {
+		return nil
+	}
+
+	var promoted []uint
+	for {
+		next, err := global.Rdb.ZRange(global.Rctx, waitlistKey(dailyRouteKey), 0, 0).Result()
+		if err != nil {
+			log.Println(err)
+			return promoted
+		}
+		if len(next) == 0 {
+			return promoted
+		}
+		teamID := next[0]
+
+		team, err := getWaitingTeam(teamID)
+		if err != nil || team.Route != route {
+			LeaveWaitlist(teamID)
+			if team != nil {
+				captain, members := model.GetPersonsInTeam(int(team.ID))
+				utility.SendSystemMessageToTeam("队伍不再满足提交条件，已被移出候补队列", captain, members)
+			}
+			continue
+		}
+
+		n, err := Submit(teamID, dailyRouteKey)
+		if err != nil {
+			log.Println(err)
+			return promoted
+		}
+		if n == SubmitFull {
+			return promoted
+		}
+
+		LeaveWaitlist(teamID)
+		if n == SubmitSuccess {
+			promoted = append(promoted, team.ID)
+			captain, members := model.GetPersonsInTeam(int(team.ID))
+			utility.SendSystemMessageToTeam("候补成功，队伍"+team.Name+"已自动提交", captain, members)
+		}
+	}
+}
+
+// getWaitingTeam 获取候补中的队伍，队伍不存在时返回 nil，不满足提交条件时返回队伍和错误
+func getWaitingTeam(teamID string) (*model.Team, error) {
+	id, err := strconv.Atoi(teamID)
+	if err != nil {
+		return nil, err
+	}
+	team, err := GetTeamByID(uint(id))
+	if err != nil {
+		return nil, err
+	}
+	if policyService.GetPolicy(team.Route).CheckSubmit(team) != nil {
+		return team, errNotEligible
+	}
+	return team, nil
+}
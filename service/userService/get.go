@@ -7,7 +7,7 @@ import (
 
 func GetUserByID(id string) (*model.Person, error) {
 	var person model.Person
-	result := global.DB.Where("identity = ?", id).First(&person)
+	result := global.DB.Where("identity_hash = ?", model.IdentityHash(id)).First(&person)
 	return &person, result.Error
 }
 
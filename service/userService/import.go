@@ -0,0 +1,315 @@
+package userService
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"walk-server/global"
+	"walk-server/model"
+	"walk-server/utility"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// 导入表格的列名，学号为可选列
+const (
+	columnName     = "姓名"
+	columnIdentity = "身份证号"
+	columnTel      = "手机号"
+	columnCampus   = "校区"
+	columnCollege  = "学院"
+	columnStuID    = "学号"
+)
+
+// ImportColumns 导入表格需要的列，错误报告也使用这些列
+var ImportColumns = []string{columnName, columnIdentity, columnTel, columnCampus, columnCollege, columnStuID}
+
+// ImportOpenIDPrefix 导入的校友登录前使用的占位 open ID 前缀
+const ImportOpenIDPrefix = "import:"
+
+// MaxImportRows 单次最多导入的行数
+const MaxImportRows = 5000
+
+var (
+	ErrImportEmpty      = errors.New("表格中没有数据")
+	ErrImportTooMany    = errors.New("单次最多导入" + strconv.Itoa(MaxImportRows) + "行")
+	ErrImportMissColumn = errors.New("缺少必需的列")
+
+	telPattern = regexp.MustCompile(`^1\d{10}$`)
+	campusMap  = map[string]uint8{"朝晖": 1, "屏峰": 2, "莫干山": 3, "1": 1, "2": 2, "3": 3}
+)
+
+// ImportRow 表格中的一行及其校验结果
+type ImportRow struct {
+	Row      int      `json:"row"` // 在表格中的行号
+	Values   []string `json:"values"`
+	Errors   []string `json:"errors"`
+	Imported bool     `json:"-"`
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Total    int         `json:"total"`
+	Imported int         `json:"imported"`
+	Failed   []ImportRow `json:"failed"`
+}
+
+// ImportAlumni 从 xlsx 表格导入校友，校验每一行并在同一事务中写入全部合法的行
+// 导入的校友使用占位 open ID，在 /register/alumnus 登录后绑定微信
+func ImportAlumni(reader io.Reader) (*ImportResult, error) {
+	rows, err := readImportRows(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	persons := make([]model.Person, 0, len(rows))
+	valid := make([]*ImportRow, 0, len(rows))
+	seen := make(map[string]int) // 表格内的重复检查，值为第一次出现的行号
+	for i := range rows {
+		row := &rows[i]
+		person := validateImportRow(row)
+		for _, key := range []string{"identity:" + person.Identity, "tel:" + person.Tel, "stu_id:" + person.StuId} {
+			if strings.HasSuffix(key, ":") {
+				continue
+			}
+			if first, ok := seen[key]; ok {
+				row.Errors = append(row.Errors, "与第"+strconv.Itoa(first)+"行重复")
+				break
+			}
+			seen[key] = row.Row
+		}
+		if len(row.Errors) == 0 {
+			persons = append(persons, person)
+			valid = append(valid, row)
+		}
+	}
+
+	// 与数据库中已有的人员比较
+	if err := checkImportDuplicates(persons, valid); err != nil {
+		return nil, err
+	}
+	toInsert := make([]model.Person, 0, len(persons))
+	for i, row := range valid {
+		if len(row.Errors) == 0 {
+			toInsert = append(toInsert, persons[i])
+			row.Imported = true
+		}
+	}
+
+	if len(toInsert) > 0 {
+		err = global.DB.Transaction(func(tx *gorm.DB) error {
+			// 没有学号时不写入学号列，避免空字符串违反唯一索引
+			var withStuID, withoutStuID []model.Person
+			for _, person := range toInsert {
+				if person.StuId == "" {
+					withoutStuID = append(withoutStuID, person)
+				} else {
+					withStuID = append(withStuID, person)
+				}
+			}
+			if len(withStuID) > 0 {
+				if err := tx.CreateInBatches(&withStuID, 500).Error; err != nil {
+					return err
+				}
+			}
+			if len(withoutStuID) > 0 {
+				if err := tx.Omit("StuId").CreateInBatches(&withoutStuID, 500).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &ImportResult{Total: len(rows), Imported: len(toInsert), Failed: []ImportRow{}}
+	for _, row := range rows {
+		if !row.Imported {
+			result.Failed = append(result.Failed, row)
+		}
+	}
+	return result, nil
+}
+
+// readImportRows 读取第一个工作表，按表头匹配列，跳过空行
+func readImportRows(reader io.Reader) ([]ImportRow, error) {
+	file, err := excelize.OpenReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, ErrImportEmpty
+	}
+	data, err := file.GetRows(sheets[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 {
+		return nil, ErrImportEmpty
+	}
+	if len(data)-1 > MaxImportRows {
+		return nil, ErrImportTooMany
+	}
+
+	// 表头中的列名对应的列号，手机号也可以写作联系电话
+	index := make(map[string]int)
+	for i, header := range data[0] {
+		header = strings.TrimSpace(header)
+		if header == "联系电话" {
+			header = columnTel
+		}
+		index[header] = i
+	}
+	for _, column := range ImportColumns[:5] {
+		if _, ok := index[column]; !ok {
+			return nil, ErrImportMissColumn
+		}
+	}
+
+	rows := make([]ImportRow, 0, len(data)-1)
+	for i, line := range data[1:] {
+		values := make([]string, len(ImportColumns))
+		empty := true
+		for j, column := range ImportColumns {
+			if k, ok := index[column]; ok && k < len(line) {
+				values[j] = strings.TrimSpace(line[k])
+				empty = empty && values[j] == ""
+			}
+		}
+		if empty {
+			continue
+		}
+		rows = append(rows, ImportRow{Row: i + 2, Values: values})
+	}
+	if len(rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+	return rows, nil
+}
+
+// validateImportRow 校验一行数据，错误记录在 row.Errors 中
+func validateImportRow(row *ImportRow) model.Person {
+	name, identity, tel, campus, college, stuID := row.Values[0], model.NormalizeIdentity(row.Values[1]), row.Values[2], row.Values[3], row.Values[4], row.Values[5]
+	row.Values[1] = identity
+
+	if name == "" {
+		row.Errors = append(row.Errors, "姓名为空")
+	} else if len([]rune(name)) > 32 {
+		row.Errors = append(row.Errors, "姓名过长")
+	}
+	gender, ok := identityGender(identity)
+	if !ok {
+		row.Errors = append(row.Errors, "身份证号格式错误")
+	}
+	if !telPattern.MatchString(tel) {
+		row.Errors = append(row.Errors, "手机号格式错误")
+	}
+	campusID, ok := campusMap[campus]
+	if !ok {
+		row.Errors = append(row.Errors, "校区应为朝晖、屏峰或莫干山")
+	}
+	if college == "" {
+		row.Errors = append(row.Errors, "学院为空")
+	} else if len([]rune(college)) > 64 {
+		row.Errors = append(row.Errors, "学院过长")
+	}
+	if len(stuID) > 32 {
+		row.Errors = append(row.Errors, "学号过长")
+	}
+
+	return model.Person{
+		OpenId:     ImportOpenIDPrefix + utility.NewFileKey(), // 随机占位，校友登录后替换为微信 open ID
+		Name:       name,
+		Gender:     gender,
+		StuId:      stuID,
+		Campus:     campusID,
+		Identity:   identity,
+		College:    college,
+		Tel:        tel,
+		CreatedOp:  2,
+		JoinOp:     5,
+		TeamId:     -1,
+		WalkStatus: 1,
+		Type:       3,
+	}
+}
+
+// identityGender 校验 18 位身份证号并根据倒数第二位返回性别（1 男，2 女）
+func identityGender(identity string) (int8, bool) {
+	if len(identity) != 18 {
+		return 0, false
+	}
+	weights := []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i := 0; i < 17; i++ {
+		if identity[i] < '0' || identity[i] > '9' {
+			return 0, false
+		}
+		sum += int(identity[i]-'0') * weights[i]
+	}
+	if "10X98765432"[sum%11] != identity[17] {
+		return 0, false
+	}
+	if (identity[16]-'0')%2 == 1 {
+		return 1, true
+	}
+	return 2, true
+}
+
+// checkImportDuplicates 检查身份证号、手机号和学号是否已存在，重复的行记录错误
+func checkImportDuplicates(persons []model.Person, rows []*ImportRow) error {
+	if len(persons) == 0 {
+		return nil
+	}
+	identities := make([]string, 0, len(persons))
+	tels := make([]string, 0, len(persons))
+	stuIDs := make([]string, 0, len(persons))
+	for _, person := range persons {
+		identities = append(identities, model.IdentityHash(person.Identity))
+		tels = append(tels, model.TelHash(person.Tel))
+		if person.StuId != "" {
+			stuIDs = append(stuIDs, person.StuId)
+		}
+	}
+
+	// 身份证号和电话已加密，通过盲索引比较
+	var existing []model.Person
+	query := global.DB.Select("identity_hash", "tel_hash", "stu_id").Where("identity_hash IN ?", identities).Or("tel_hash IN ?", tels)
+	if len(stuIDs) > 0 {
+		query = query.Or("stu_id IN ?", stuIDs)
+	}
+	if err := query.Find(&existing).Error; err != nil {
+		return err
+	}
+
+	existIdentity := make(map[string]bool, len(existing))
+	existTel := make(map[string]bool, len(existing))
+	existStuID := make(map[string]bool, len(existing))
+	for _, person := range existing {
+		existIdentity[person.IdentityHash] = true
+		existTel[person.TelHash] = true
+		if person.StuId != "" {
+			existStuID[person.StuId] = true
+		}
+	}
+	for i, person := range persons {
+		if existIdentity[identities[i]] {
+			rows[i].Errors = append(rows[i].Errors, "身份证号已存在")
+		}
+		if existTel[tels[i]] {
+			rows[i].Errors = append(rows[i].Errors, "手机号已存在")
+		}
+		if person.StuId != "" && existStuID[person.StuId] {
+			rows[i].Errors = append(rows[i].Errors, "学号已存在")
+		}
+	}
+	return nil
+}
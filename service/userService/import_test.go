@@ -0,0 +1,67 @@
+package userService
+
+import (
+	"strings"
+	"testing"
+	"walk-server/model"
+)
+
+func TestIdentityGender(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity string
+		gender   int8
+		ok       bool
+	}{
+		{"男", "330106200001010013", 1, true},
+		{"女，末位为 X", "11010519491231002X", 2, true},
+		{"末位 X 的男性", "33010620000101003X", 1, true},
+		{"校验位错误", "330106200001010014", 0, false},
+		{"小写 x 需先统一格式", "11010519491231002x", 0, false},
+		{"长度不足", "33010620000101001", 0, false},
+		{"15 位旧号码", "330106000101001", 0, false},
+		{"包含字母", "3301062000010100A3", 0, false},
+		{"空字符串", "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gender, ok := identityGender(tt.identity)
+			if gender != tt.gender || ok != tt.ok {
+				t.Errorf("identityGender(%q) = (%d, %v), want (%d, %v)", tt.identity, gender, ok, tt.gender, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidateImportRowNormalizesIdentity(t *testing.T) {
+	row := &ImportRow{Values: []string{"张三", " 11010519491231002x ", "13800000000", "朝晖", "计算机学院", ""}}
+	person := validateImportRow(row)
+	if len(row.Errors) != 0 {
+		t.Fatalf("unexpected errors: %v", row.Errors)
+	}
+	if person.Identity != "11010519491231002X" {
+		t.Errorf("Identity = %q, want %q", person.Identity, "11010519491231002X")
+	}
+	if person.Gender != 2 {
+		t.Errorf("Gender = %d, want 2", person.Gender)
+	}
+}
+
+// 占位 open ID 是随机的，不能包含身份证号，也不能在两次导入之间重复
+func TestValidateImportRowOpenID(t *testing.T) {
+	values := []string{"张三", "11010519491231002X", "13800000000", "朝晖", "计算机学院", ""}
+	first := validateImportRow(&ImportRow{Values: append([]string(nil), values...)})
+	second := validateImportRow(&ImportRow{Values: append([]string(nil), values...)})
+
+	for _, person := range []model.Person{first, second} {
+		if !strings.HasPrefix(person.OpenId, ImportOpenIDPrefix) {
+			t.Errorf("OpenId = %q, want prefix %q", person.OpenId, ImportOpenIDPrefix)
+		}
+		if strings.Contains(person.OpenId, "11010519491231002") {
+			t.Errorf("OpenId = %q contains the identity number", person.OpenId)
+		}
+	}
+	if first.OpenId == second.OpenId {
+		t.Errorf("two imports got the same OpenId %q", first.OpenId)
+	}
+}
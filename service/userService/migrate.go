@@ -0,0 +1,46 @@
+package userService
+
+import (
+	"log"
+	"walk-server/global"
+	"walk-server/model"
+)
+
+// encryptBatchSize 迁移时每批处理的人数
+const encryptBatchSize = 500
+
+// EncryptExisting 加密数据库中仍为明文的身份证号和电话并补全盲索引，返回迁移成功和失败的人数
+// 可以重复执行，已迁移的记录会被跳过；失败的记录（如盲索引重复）只记录日志，需要人工处理
+func EncryptExisting() (migrated int, failed int, err error) {
+	lastOpenID := ""
+	for {
+		var persons []model.Person
+		err = global.DB.
+			Where("open_id > ?", lastOpenID).
+			Where("identity NOT LIKE ? OR tel NOT LIKE ? OR identity_hash IS NULL OR tel_hash IS NULL", "enc:%", "enc:%").
+			Order("open_id").Limit(encryptBatchSize).
+			Find(&persons).Error
+		if err != nil {
+			return
+		}
+		if len(persons) == 0 {
+			return
+		}
+
+		for i := range persons {
+			person := &persons[i]
+			// 读取时明文原样返回，写回时由序列化器加密，盲索引在 BeforeSave 中计算
+			result := global.DB.Model(person).
+				Select("Identity", "Tel", "IdentityHash", "TelHash").
+				Updates(person)
+			if result.Error != nil {
+				log.Printf("加密用户 %s 的数据失败: %v\n", person.OpenId, result.Error)
+				failed++
+				continue
+			}
+			migrated++
+		}
+		lastOpenID = persons[len(persons)-1].OpenId
+		log.Printf("已加密 %d 人，失败 %d 人\n", migrated, failed)
+	}
+}
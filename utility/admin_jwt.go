@@ -0,0 +1,61 @@
+package utility
+
+import (
+	"errors"
+	"time"
+	"walk-server/global"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 管理员 token 的类型
+const (
+	AdminAccessToken  = "access"  // 访问接口使用，有效期短
+	AdminRefreshToken = "refresh" // 只能用来换取新的 token
+)
+
+// AdminClaims 管理员 token 中的数据，接口鉴权时不再查询数据库
+type AdminClaims struct {
+	AdminID            uint   `json:"admin_id"`
+	Name               string `json:"name"`
+	Role               uint8  `json:"role"`
+	Route              uint8  `json:"route"`
+	Point              int8   `json:"point"`
+	MustChangePassword bool   `json:"must_change_password"`
+	SessionID          string `json:"sid"` // 登录会话ID，同一次登录刷新出的 token 共用，用于吊销
+	Type               string `json:"type"`
+	jwt.RegisteredClaims
+}
+
+// 管理员 token 使用单独的密钥签名，避免和用户 token 混用
+func adminSecret() []byte {
+	return []byte(global.Config.GetString("server.JWTSecret") + ":admin")
+}
+
+// GenerateAdminJwt 生成在 ttl 后过期的管理员 token
+func GenerateAdminJwt(claims *AdminClaims, ttl time.Duration) (string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		NotBefore: jwt.NewNumericDate(time.Now()),
+		Issuer:    "JHWL",
+	}
+
+	tokenClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return tokenClaims.SignedString(adminSecret())
+}
+
+// ParseAdminToken 校验管理员 token 的签名、有效期和类型
+func ParseAdminToken(token string, tokenType string) (*AdminClaims, error) {
+	tokenClaims, err := jwt.ParseWithClaims(token, &AdminClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return adminSecret(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := tokenClaims.Claims.(*AdminClaims)
+	if !ok || !tokenClaims.Valid || claims.Type != tokenType {
+		return nil, errors.New("invalid admin token")
+	}
+	return claims, nil
+}
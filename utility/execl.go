@@ -2,9 +2,9 @@ package utility
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 
 	"log"
@@ -86,13 +86,12 @@ var (
 	centeredStyleID int
 )
 
-// CreateExcelFile 生成 Excel 文件
-func CreateExcelFile(data File, fileName, filePath, host string) (string, error) {
-	if err := validateFileData(data); err != nil {
-		return "", fmt.Errorf("无效的文件数据: %w", err)
+// CreateExcelFile 生成 Excel 文件，保存在 FileDir 下的随机目录中，返回有时效的下载链接
+func CreateExcelFile(data File, fileName string) (string, error) {
+	f, err := buildExcelFile(data)
+	if err != nil {
+		return "", err
 	}
-
-	f := excelize.NewFile()
 	// 使用 defer 确保在任何情况下都能关闭文件
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -100,38 +99,173 @@ func CreateExcelFile(data File, fileName, filePath, host string) (string, error)
 		}
 	}()
 
+	filePath := filepath.Join(FileDir, NewFileKey())
+	if err := ensureDirExists(filePath); err != nil {
+		return "", fmt.Errorf("确保目录存在失败: %w", err)
+	}
+
+	fullPath := filepath.Join(filePath, filepath.Base(fileName))
+	if err := f.SaveAs(fullPath); err != nil {
+		return "", fmt.Errorf("保存 Excel 文件失败: %w", err)
+	}
+
+	return SignFileURL(fullPath)
+}
+
+// buildExcelFile 校验数据并逐个工作表流式写入，调用方负责关闭返回的文件
+func buildExcelFile(data File) (*excelize.File, error) {
+	if err := validateFileData(data); err != nil {
+		return nil, fmt.Errorf("无效的文件数据: %w", err)
+	}
+
+	f := excelize.NewFile()
+
 	// 初始化样式
 	if err := initStyles(f); err != nil {
-		return "", fmt.Errorf("初始化样式失败: %w", err)
+		f.Close()
+		return nil, fmt.Errorf("初始化样式失败: %w", err)
 	}
 
 	// 处理每个工作表
 	for i, sheet := range data.Sheets {
 		if err := createSheet(f, sheet, i); err != nil {
-			return "", fmt.Errorf("创建工作表 '%s' 失败: %w", sheet.Name, err)
+			f.Close()
+			return nil, fmt.Errorf("创建工作表 '%s' 失败: %w", sheet.Name, err)
 		}
 	}
 
 	f.SetActiveSheet(0)
+	return f, nil
+}
 
-	fullPath := filepath.Join(filePath, fileName)
-	if err := ensureDirExists(filePath); err != nil {
-		return "", fmt.Errorf("确保目录存在失败: %w", err)
+// ExcelStream 逐批写入数据行的 Excel 文件，用于行数较多、无法一次性放入内存的导出
+// 数据行不在内存中保留，列宽只根据列名设置
+type ExcelStream struct {
+	f      *excelize.File
+	sw     *excelize.StreamWriter
+	sheets int
+	row    int
+	cols   int
+}
+
+// NewExcelStream 创建逐批写入的 Excel 文件，使用完后需要调用 Close
+func NewExcelStream() (*ExcelStream, error) {
+	f := excelize.NewFile()
+	if err := initStyles(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("初始化样式失败: %w", err)
 	}
+	return &ExcelStream{f: f}, nil
+}
 
-	if err := removeOldFile(fullPath); err != nil {
-		log.Printf("删除旧文件失败: %v", err)
+// Sheets 已创建的工作表数量
+func (s *ExcelStream) Sheets() int {
+	return s.sheets
+}
+
+// AddSheet 结束上一个工作表并创建新的工作表，写入列名
+func (s *ExcelStream) AddSheet(name string, headers []string) error {
+	if len(name) == 0 || len(name) > MaxSheetNameLen {
+		return fmt.Errorf("%w: 工作表名称长度应为 1 到 %d", ErrInvalidFileData, MaxSheetNameLen)
+	}
+	if len(headers) == 0 {
+		return fmt.Errorf("%w: 工作表 '%s' 没有列名", ErrInvalidFileData, name)
+	}
+	if err := s.flush(); err != nil {
+		return err
 	}
 
-	if err := f.SaveAs(fullPath); err != nil {
-		return "", fmt.Errorf("保存 Excel 文件失败: %w", err)
+	if s.sheets == 0 {
+		if err := s.f.SetSheetName(DefaultSheetName, name); err != nil {
+			return fmt.Errorf("重命名默认工作表失败: %w", err)
+		}
+	} else if _, err := s.f.NewSheet(name); err != nil {
+		return fmt.Errorf("创建新工作表失败: %w", err)
+	}
+	sw, err := s.f.NewStreamWriter(name)
+	if err != nil {
+		return fmt.Errorf("创建流式写入器失败: %w", err)
 	}
 
-	if !strings.HasSuffix(host, DefaultHostSuffix) {
-		host += DefaultHostSuffix
+	// 列宽需要在写入数据前设置
+	for i, header := range headers {
+		width := len(header)
+		if width < MinColumnWidth*2 {
+			width = MinColumnWidth * 2
+		}
+		if err := sw.SetColWidth(i+1, i+1, float64(width)); err != nil {
+			return fmt.Errorf("设置列宽失败，列索引: %d: %w", i+1, err)
+		}
+	}
+	if err := writeHeaders(sw, headers); err != nil {
+		return fmt.Errorf("写入列名失败: %w", err)
 	}
 
-	return host + fullPath, nil
+	s.sw = sw
+	s.sheets++
+	s.row = 1
+	s.cols = len(headers)
+	return nil
+}
+
+// WriteRows 向当前工作表追加数据行
+func (s *ExcelStream) WriteRows(rows [][]any) error {
+	if s.sw == nil {
+		return fmt.Errorf("%w: 没有提供工作表", ErrInvalidFileData)
+	}
+	for _, row := range rows {
+		if len(row) != s.cols {
+			return fmt.Errorf("%w: 第 %d 行长度与列名长度不匹配", ErrInvalidFileData, s.row)
+		}
+		cells := make([]interface{}, len(row))
+		for i, cell := range row {
+			c := excelize.Cell{Value: cell}
+			if i < MergeCheckColumns {
+				c.StyleID = centeredStyleID
+			}
+			cells[i] = c
+		}
+		s.row++
+		cellRef, _ := excelize.CoordinatesToCellName(1, s.row)
+		if err := s.sw.SetRow(cellRef, cells); err != nil {
+			return fmt.Errorf("%w: %v", ErrWriteRowsFailed, err)
+		}
+	}
+	return nil
+}
+
+// Output 结束最后一个工作表并将文件写入 w
+func (s *ExcelStream) Output(w io.Writer) error {
+	if s.sheets == 0 {
+		return fmt.Errorf("%w: 没有提供工作表", ErrInvalidFileData)
+	}
+	if err := s.flush(); err != nil {
+		return err
+	}
+	s.f.SetActiveSheet(0)
+	if _, err := s.f.WriteTo(w); err != nil {
+		return fmt.Errorf("写出 Excel 文件失败: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭文件，删除流式写入产生的临时文件
+func (s *ExcelStream) Close() {
+	if err := s.f.Close(); err != nil {
+		log.Printf("关闭 Excel 文件失败: %v", err)
+	}
+}
+
+func (s *ExcelStream) flush() error {
+	if s.sw == nil {
+		return nil
+	}
+	err := s.sw.Flush()
+	s.sw = nil
+	if err != nil {
+		return fmt.Errorf("刷新工作表数据失败: %w", err)
+	}
+	return nil
 }
 
 // initStyles 初始化样式
@@ -402,13 +536,3 @@ func ensureDirExists(filePath string) error {
 	}
 	return nil
 }
-
-// removeOldFile 删除旧文件
-func removeOldFile(fullPath string) error {
-	if _, err := os.Stat(fullPath); err == nil {
-		if err := os.Remove(fullPath); err != nil {
-			return fmt.Errorf("%w: %v", ErrRemoveFileFailed, err)
-		}
-	}
-	return nil
-}
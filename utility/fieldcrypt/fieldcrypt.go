@@ -0,0 +1,142 @@
+package fieldcrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"walk-server/global"
+
+	"gorm.io/gorm/schema"
+)
+
+// 密文的前缀，没有前缀的值是加密前写入的明文
+const prefix = "enc:"
+
+var ErrCiphertext = errors.New("invalid ciphertext")
+
+var (
+	once     sync.Once
+	aead     cipher.AEAD
+	indexKey []byte
+)
+
+func init() {
+	schema.RegisterSerializer("encrypt", Serializer{})
+}
+
+// 从 server.fieldSecret 派生加密密钥和盲索引密钥，未配置时使用 server.AESSecret
+// 修改密钥后已加密的数据将无法解密，盲索引也会失效
+func keys() (cipher.AEAD, []byte) {
+	once.Do(func() {
+		secret := global.Config.GetString("server.fieldSecret")
+		if secret == "" {
+			secret = global.Config.GetString("server.AESSecret")
+		}
+		encKey := sha256.Sum256([]byte(secret + ":encrypt"))
+		block, err := aes.NewCipher(encKey[:])
+		if err != nil {
+			panic(err)
+		}
+		aead, err = cipher.NewGCM(block)
+		if err != nil {
+			panic(err)
+		}
+		idxKey := sha256.Sum256([]byte(secret + ":index"))
+		indexKey = idxKey[:]
+	})
+	return aead, indexKey
+}
+
+// IsEncrypted 判断数据库中的值是否已经加密
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Encrypt 使用 AES-GCM 加密，每次加密使用随机 nonce，空字符串不加密
+func Encrypt(plain string) (string, error) {
+	if plain == "" {
+		return "", nil
+	}
+	gcm, _ := keys()
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return prefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 的结果，没有加密前缀的值按明文原样返回
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(value[len(prefix):])
+	if err != nil {
+		return "", ErrCiphertext
+	}
+	gcm, _ := keys()
+	if len(sealed) < gcm.NonceSize() {
+		return "", ErrCiphertext
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrCiphertext
+	}
+	return string(plain), nil
+}
+
+// BlindIndex 计算用于唯一约束和等值查询的盲索引，不同字段使用不同的域
+// 值按原样计算，调用方需要先统一格式（如 model.NormalizeIdentity）
+func BlindIndex(field, value string) string {
+	if value == "" {
+		return ""
+	}
+	_, key := keys()
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Serializer gorm 序列化器，字段标签为 serializer:encrypt，写入时加密，读取时解密
+type Serializer struct{}
+
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return fmt.Errorf("fieldcrypt: unsupported value type %T", dbValue)
+	}
+
+	plain, err := Decrypt(value)
+	if err != nil {
+		return err
+	}
+	field.ReflectValueOf(ctx, dst).SetString(plain)
+	return nil
+}
+
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plain, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("fieldcrypt: unsupported field type %T", fieldValue)
+	}
+	return Encrypt(plain)
+}
@@ -0,0 +1,117 @@
+package fieldcrypt
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"walk-server/global"
+)
+
+func TestMain(m *testing.M) {
+	global.Config.Set("server.fieldSecret", "test-field-secret")
+	os.Exit(m.Run())
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	tests := []struct {
+		name  string
+		plain string
+	}{
+		{"身份证号", "11010519491231002X"},
+		{"手机号", "13800000000"},
+		{"中文", "浙江工业大学"},
+		{"空字符串", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cipherText, err := Encrypt(tt.plain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.plain == "" {
+				if cipherText != "" {
+					t.Errorf("Encrypt(%q) = %q, want empty", tt.plain, cipherText)
+				}
+				return
+			}
+			if !IsEncrypted(cipherText) || strings.Contains(cipherText, tt.plain) {
+				t.Errorf("Encrypt(%q) = %q, not encrypted", tt.plain, cipherText)
+			}
+			plain, err := Decrypt(cipherText)
+			if err != nil || plain != tt.plain {
+				t.Errorf("Decrypt(Encrypt(%q)) = (%q, %v)", tt.plain, plain, err)
+			}
+		})
+	}
+}
+
+func TestEncryptRandomNonce(t *testing.T) {
+	a, _ := Encrypt("13800000000")
+	b, _ := Encrypt("13800000000")
+	if a == b {
+		t.Error("encrypting the same value twice produced the same ciphertext")
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	valid, err := Encrypt("13800000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(valid[len(prefix):])
+	body[len(body)/2] ^= 1
+	tampered := prefix + string(body)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"未加密的明文原样返回", "13800000000", "13800000000", false},
+		{"空字符串", "", "", false},
+		{"正常密文", valid, "13800000000", false},
+		{"篡改的密文", tampered, "", true},
+		{"截断的密文", valid[:len(prefix)+8], "", true},
+		{"非 base64", prefix + "!!!", "", true},
+		{"只有前缀", prefix, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decrypt(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("Decrypt(%q) = (%q, %v), want (%q, wantErr=%v)", tt.value, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	identity := BlindIndex("identity", "11010519491231002X")
+	if len(identity) != 64 {
+		t.Fatalf("BlindIndex length = %d, want 64", len(identity))
+	}
+
+	tests := []struct {
+		name  string
+		field string
+		value string
+		equal bool
+	}{
+		{"相同的值", "identity", "11010519491231002X", true},
+		{"不同的值", "identity", "110105194912310029", false},
+		{"不同的字段", "tel", "11010519491231002X", false},
+		{"按原样计算，不统一大小写", "identity", "11010519491231002x", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BlindIndex(tt.field, tt.value); (got == identity) != tt.equal {
+				t.Errorf("BlindIndex(%q, %q) equal = %v, want %v", tt.field, tt.value, got == identity, tt.equal)
+			}
+		})
+	}
+
+	if got := BlindIndex("identity", ""); got != "" {
+		t.Errorf("BlindIndex of empty value = %q, want empty", got)
+	}
+}
@@ -0,0 +1,176 @@
+package utility
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"walk-server/global"
+)
+
+// FileDir 生成的文件（导出表格、海报）的保存目录，只能通过签名链接下载
+const FileDir = "./file/"
+
+var ErrInvalidFilePath = errors.New("invalid file path")
+
+// 文件链接使用单独的密钥签名
+func fileSecret() []byte {
+	return []byte(global.Config.GetString("server.JWTSecret") + ":file")
+}
+
+// FileURLTTL 下载链接的有效期，由 file.urlTTL 配置（分钟），默认 30 分钟
+func FileURLTTL() time.Duration {
+	ttl := global.Config.GetInt("file.urlTTL")
+	if ttl <= 0 {
+		ttl = 30
+	}
+	return time.Duration(ttl) * time.Minute
+}
+
+// FileRetention 文件的保留时间，由 file.retention 配置（小时），默认 24 小时，不会短于链接有效期
+func FileRetention() time.Duration {
+	retention := time.Duration(global.Config.GetInt("file.retention")) * time.Hour
+	if retention <= 0 {
+		retention = 24 * time.Hour
+	}
+	if ttl := FileURLTTL(); retention < ttl {
+		retention = ttl
+	}
+	return retention
+}
+
+// NewFileKey 生成随机的文件目录名，使文件路径无法被猜到
+func NewFileKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// CleanFilePath 将 FileDir 下的文件路径转换为相对路径，路径不在 FileDir 下时返回错误
+func CleanFilePath(fullPath string) (string, error) {
+	rel, err := filepath.Rel(FileDir, fullPath)
+	if err != nil {
+		return "", err
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", ErrInvalidFilePath
+	}
+	return rel, nil
+}
+
+func signFile(rel string, expires int64) string {
+	mac := hmac.New(sha256.New, fileSecret())
+	mac.Write([]byte(rel))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignFileURL 生成 FileDir 下文件的下载链接，链接在 FileURLTTL 后过期
+func SignFileURL(fullPath string) (string, error) {
+	rel, err := CleanFilePath(fullPath)
+	if err != nil {
+		return "", err
+	}
+
+	expires := time.Now().Add(FileURLTTL()).Unix()
+	segments := strings.Split(rel, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	host := global.Config.GetString("frontend.url")
+	if !strings.HasSuffix(host, DefaultHostSuffix) {
+		host += DefaultHostSuffix
+	}
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("sign", signFile(rel, expires))
+	return host + "file/" + strings.Join(segments, "/") + "?" + query.Encode(), nil
+}
+
+// VerifyFileURL 校验下载链接的签名和有效期，成功时返回文件的本地路径
+func VerifyFileURL(rel, expires, sign string) (string, bool) {
+	rel = strings.TrimPrefix(path.Clean("/"+rel), "/")
+	if rel == "" {
+		return "", false
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return "", false
+	}
+	if !hmac.Equal([]byte(signFile(rel, expiresAt)), []byte(sign)) {
+		return "", false
+	}
+	return filepath.Join(FileDir, filepath.FromSlash(rel)), true
+}
+
+// CleanExpiredFiles 删除超过保留时间的文件和空目录，返回删除的文件数
+func CleanExpiredFiles() int {
+	deadline := time.Now().Add(-FileRetention())
+	removed := 0
+	var dirs []string
+	err := filepath.WalkDir(FileDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != filepath.Clean(FileDir) {
+				dirs = append(dirs, p)
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().Before(deadline) {
+			if err := os.Remove(p); err != nil {
+				log.Println(err)
+			} else {
+				removed++
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Println(err)
+	}
+
+	// 从最深的目录开始删除空目录，非空目录删除失败时忽略
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if entries, err := os.ReadDir(dirs[i]); err == nil && len(entries) == 0 {
+			os.Remove(dirs[i])
+		}
+	}
+	return removed
+}
+
+// RunCleanFiles 定时清理过期的文件，间隔由 file.cleanInterval 配置（分钟），0 为关闭
+func RunCleanFiles() {
+	interval := global.Config.GetInt("file.cleanInterval")
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		if removed := CleanExpiredFiles(); removed > 0 {
+			log.Printf("清理过期文件 %d 个\n", removed)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package utility
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"walk-server/global"
+)
+
+func TestMain(m *testing.M) {
+	global.Config.Set("server.JWTSecret", "test-jwt-secret")
+	os.Exit(m.Run())
+}
+
+func TestVerifyFileURL(t *testing.T) {
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Minute).Unix()
+	rel := "0123456789abcdef/roster.xlsx"
+	sign := signFile(rel, future)
+
+	tests := []struct {
+		name    string
+		rel     string
+		expires string
+		sign    string
+		want    string
+		ok      bool
+	}{
+		{"正常链接", rel, strconv.FormatInt(future, 10), sign, filepath.Join(FileDir, rel), true},
+		{"已过期", rel, strconv.FormatInt(past, 10), signFile(rel, past), "", false},
+		{"延长有效期", rel, strconv.FormatInt(future+3600, 10), sign, "", false},
+		{"有效期不是数字", rel, "tomorrow", sign, "", false},
+		{"篡改签名", rel, strconv.FormatInt(future, 10), strings.Repeat("0", len(sign)), "", false},
+		{"签名为空", rel, strconv.FormatInt(future, 10), "", "", false},
+		{"使用其他文件的签名", "0123456789abcdef/other.xlsx", strconv.FormatInt(future, 10), sign, "", false},
+		{"路径穿越", "../config/config.yaml", strconv.FormatInt(future, 10), sign, "", false},
+		{"目录内的路径穿越", "0123456789abcdef/../../config/config.yaml", strconv.FormatInt(future, 10), sign, "", false},
+		{"路径为空", "", strconv.FormatInt(future, 10), signFile("", future), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := VerifyFileURL(tt.rel, tt.expires, tt.sign)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("VerifyFileURL(%q, %q, %q) = (%q, %v), want (%q, %v)", tt.rel, tt.expires, tt.sign, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// 即使路径穿越的链接带有合法签名，清理后的路径也只能指向 FileDir 内的文件
+func TestVerifyFileURLStaysInFileDir(t *testing.T) {
+	future := time.Now().Add(time.Hour).Unix()
+	for _, rel := range []string{"../config/config.yaml", "a/../../../etc/passwd", "/etc/passwd"} {
+		cleaned := strings.TrimPrefix(filepath.ToSlash(filepath.Clean("/"+rel)), "/")
+		got, ok := VerifyFileURL(rel, strconv.FormatInt(future, 10), signFile(cleaned, future))
+		if !ok {
+			t.Fatalf("VerifyFileURL(%q) rejected a correctly signed path", rel)
+		}
+		if _, err := CleanFilePath(got); err != nil {
+			t.Errorf("VerifyFileURL(%q) = %q, outside %s", rel, got, FileDir)
+		}
+	}
+}
+
+func TestCleanFilePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		fullPath string
+		want     string
+		wantErr  bool
+	}{
+		{"目录内的文件", FileDir + "abc/roster.xlsx", "abc/roster.xlsx", false},
+		{"目录本身", FileDir, "", true},
+		{"目录外的文件", "./config/config.yaml", "", true},
+		{"路径穿越", FileDir + "../config/config.yaml", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CleanFilePath(tt.fullPath)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("CleanFilePath(%q) = (%q, %v), want (%q, wantErr=%v)", tt.fullPath, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
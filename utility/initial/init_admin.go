@@ -0,0 +1,21 @@
+package initial
+
+import (
+	"fmt"
+	"os"
+	"walk-server/service/adminService"
+)
+
+// AdminInit 迁移明文密码并按配置设置超级管理员
+func AdminInit() {
+	if err := adminService.MigratePasswords(); err != nil {
+		fmt.Println("管理员密码迁移失败")
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	if err := adminService.PromoteSuperAdmins(); err != nil {
+		fmt.Println("超级管理员设置失败")
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+}
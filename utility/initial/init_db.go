@@ -32,7 +32,7 @@ func DBInit() {
 	}
 
 	// 这个地方需要填入要迁移的表
-	err = global.DB.AutoMigrate(&model.Person{}, &model.Team{}, &model.Message{}, model.Admin{}, model.Form{})
+	err = global.DB.AutoMigrate(&model.Person{}, &model.Team{}, &model.Message{}, model.Admin{}, &model.Route{}, &model.RoutePoint{}, &model.Checkpoint{}, &model.QuotaLog{}, &model.Invitation{}, &model.JoinRequest{}, &model.AuditLog{}, &model.Broadcast{}, &model.BroadcastReceipt{})
 	if err != nil {
 		fmt.Println("数据表创建错误")
 		os.Exit(-1)
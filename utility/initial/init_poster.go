@@ -0,0 +1,13 @@
+package initial
+
+import (
+	"log"
+	"walk-server/utility"
+)
+
+// PosterInit 加载海报字体，加载失败时只记录日志，其他功能照常启动，获取海报时返回 utility.ErrPosterFont
+func PosterInit() {
+	if err := utility.LoadPosterFont(); err != nil {
+		log.Println("海报字体加载失败，海报功能不可用，请检查 poster.fontPath 配置:", err)
+	}
+}
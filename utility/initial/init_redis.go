@@ -3,8 +3,8 @@ package initial
 import (
 	"fmt"
 	"os"
-	"strconv"
 	"walk-server/global"
+	"walk-server/service/quotaService"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -31,13 +31,5 @@ func RedisInit() {
 	}
 
 	// 初始化每天各路线报名上限
-	for i := 0; i <= 2; i++ { // 枚举天数
-		for j := 1; j <= 5; j++ { // 枚举路线编号
-			key := strconv.Itoa(i*10 + j)
-			value := global.Config.GetInt("teamUpperLimit" + "." + strconv.Itoa(i) + "." + strconv.Itoa(j))
-			if _, err := global.Rdb.Get(global.Rctx, key).Result(); err == redis.Nil {
-				global.Rdb.Set(global.Rctx, key, value, 0)
-			}
-		}
-	}
+	quotaService.Init()
 }
@@ -0,0 +1,16 @@
+package initial
+
+import (
+	"fmt"
+	"os"
+	"walk-server/service/routeService"
+)
+
+// RouteInit 加载路线目录
+func RouteInit() {
+	if err := routeService.Load(); err != nil {
+		fmt.Println("路线目录加载失败")
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+}
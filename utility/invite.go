@@ -0,0 +1,49 @@
+package utility
+
+import (
+	"time"
+	"walk-server/global"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// InviteData 队伍邀请 token 中的数据
+type InviteData struct {
+	InviteID uint `json:"invite_id"`
+	TeamID   uint `json:"team_id"`
+	jwt.RegisteredClaims
+}
+
+// 邀请 token 使用单独的密钥签名，避免被当作登录 token 使用
+func inviteSecret() []byte {
+	return []byte(global.Config.GetString("server.JWTSecret") + ":invite")
+}
+
+// GenerateInviteToken 生成在 expiresAt 过期的邀请 token
+func GenerateInviteToken(inviteData *InviteData, expiresAt time.Time) (string, error) {
+	claims := inviteData
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		NotBefore: jwt.NewNumericDate(time.Now()),
+		Issuer:    "JHWL",
+	}
+
+	tokenClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return tokenClaims.SignedString(inviteSecret())
+}
+
+// ParseInviteToken 校验邀请 token 的签名和有效期
+func ParseInviteToken(token string) (*InviteData, error) {
+	tokenClaims, err := jwt.ParseWithClaims(token, &InviteData{}, func(token *jwt.Token) (interface{}, error) {
+		return inviteSecret(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := tokenClaims.Claims.(*InviteData)
+	if !ok || !tokenClaims.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
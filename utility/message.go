@@ -1,13 +1,8 @@
 package utility
 
 import (
-	"errors"
-	"fmt"
 	"log"
-	"walk-server/global"
 	"walk-server/model"
-
-	"github.com/go-resty/resty/v2"
 )
 
 // SendMessageToMembers 队长将消息发给队员
@@ -19,6 +14,7 @@ func SendMessageToMembers(message string, captain model.Person, members []model.
 			SenderOpenId:   captain.OpenId,
 			ReceiverOpenId: member.OpenId,
 			Message:        message,
+			Category:       model.MessageTeam,
 		})
 
 		SendMessageWithWechat(message, member.OpenId)
@@ -27,8 +23,17 @@ func SendMessageToMembers(message string, captain model.Person, members []model.
 	model.InsertMessages(&messages)
 }
 
-// SendMessageToTeam 系统发送消息给所有的队员
+// SendMessageToTeam 系统发送队伍变动的消息给所有的队员
 func SendMessageToTeam(message string, captain model.Person, members []model.Person) {
+	sendMessageToTeam(message, model.MessageTeam, captain, members)
+}
+
+// SendSystemMessageToTeam 系统发送与队伍变动无关的通知（如候补结果）给所有的队员
+func SendSystemMessageToTeam(message string, captain model.Person, members []model.Person) {
+	sendMessageToTeam(message, model.MessageSystem, captain, members)
+}
+
+func sendMessageToTeam(message string, category uint8, captain model.Person, members []model.Person) {
 	var messages []model.Message
 
 	// 添加发给队长的消息
@@ -36,6 +41,7 @@ func SendMessageToTeam(message string, captain model.Person, members []model.Per
 		Message:        message,
 		SenderOpenId:   "",
 		ReceiverOpenId: captain.OpenId,
+		Category:       category,
 	})
 	SendMessageWithWechat(message, captain.OpenId)
 
@@ -44,6 +50,7 @@ func SendMessageToTeam(message string, captain model.Person, members []model.Per
 			Message:        message,
 			SenderOpenId:   "",
 			ReceiverOpenId: member.OpenId,
+			Category:       category,
 		})
 
 		SendMessageWithWechat(message, member.OpenId)
@@ -52,56 +59,24 @@ func SendMessageToTeam(message string, captain model.Person, members []model.Per
 	model.InsertMessages(&messages)
 }
 
-// SendMessage 人和人发送消息
+// SendMessage 人和人发送队伍变动的消息，sender 为 nil 时为系统发送
 func SendMessage(message string, sender *model.Person, receiver *model.Person) {
 	if sender == nil { // 系统消息
-		model.InsertMessage(message, "", receiver.OpenId)
+		model.InsertMessage(message, model.MessageTeam, "", receiver.OpenId)
 	} else {
-		model.InsertMessage(message, sender.OpenId, receiver.OpenId)
+		model.InsertMessage(message, model.MessageTeam, sender.OpenId, receiver.OpenId)
 	}
 
 	SendMessageWithWechat(message, receiver.OpenId)
 }
 
-func DeleteMessage(id uint, jwtData *JwtData) error {
-	// 校验是否是接收者删除了自己的消息
-	var message model.Message
-	global.DB.Where("id = ?", id).Take(&message)
-	if message.ReceiverOpenId != jwtData.OpenID {
-		return errors.New("access denied")
-	}
-
-	model.DeleteMessage(id)
-	return nil
-}
-
+// SendMessageWithWechat 将消息放入通知队列，由后台 worker 通过默认渠道推送，不会阻塞请求
 func SendMessageWithWechat(message string, receiverEncOpenID string) {
-	var accessToken string
-	var err error
-	wechatAPPID := global.Config.GetString("server.wechatAPPID")
-	wechatSecret := global.Config.GetString("server.wechatSecret")
-	accessToken, err = GetAccessToken(wechatAPPID, wechatSecret)
-
+	err := Enqueue(&Notification{
+		Receiver: receiverEncOpenID,
+		Message:  message,
+	})
 	if err != nil {
 		log.Println(err)
-		return
-	}
-
-	// 解密 open ID
-	aesKey := global.Config.GetString("server.AESSecret")
-	receiverOpenID := AesDecrypt(receiverEncOpenID, aesKey)
-
-	client := resty.New()
-	resp, err := client.R().SetBody(map[string]interface{}{
-		"touser":  receiverOpenID,
-		"msgtype": "text",
-		"text": map[string]interface{}{
-			"content": message + "\n---\n因为微信的限制，请回复'收到'以确保后续消息的正常接收",
-		},
-	}).Post("https://api.weixin.qq.com/cgi-bin/message/custom/send?access_token=" + accessToken)
-
-	if IsDebugMode() {
-		fmt.Println(string(resp.Body()))
-		fmt.Println(err)
 	}
 }
@@ -0,0 +1,263 @@
+package utility
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+	"walk-server/global"
+
+	"github.com/goccy/go-json"
+	"github.com/redis/go-redis/v9"
+)
+
+// 通知队列使用的 Redis 键
+const (
+	notifyQueueKey = "notify:queue" // 待发送的通知
+	notifyRetryKey = "notify:retry" // 等待重试的通知，分数为下次发送的毫秒时间戳
+	notifyDeadKey  = "notify:dead"  // 超过重试次数或无法重试的通知
+	notifyRateKey  = "notify:rate:" // 各渠道每秒的发送数量
+
+	notifyDeadLimit = 10000 // 死信最多保留的条数
+)
+
+// Notification 一条待发送的通知，Kind 和 RefID 用于在发送结束后回调业务代码
+type Notification struct {
+	ID        string            `json:"id"`
+	Channel   string            `json:"channel"`
+	Receiver  string            `json:"receiver"` // 加密后的 open ID
+	Message   string            `json:"message"`
+	Data      map[string]string `json:"data,omitempty"` // 模板消息等渠道使用的额外数据
+	Kind      string            `json:"kind,omitempty"`
+	RefID     uint              `json:"ref_id,omitempty"`
+	Attempts  int               `json:"attempts"`
+	LastError string            `json:"last_error,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// NotifyHandler 通知发送成功（err 为 nil）或最终失败时的回调
+type NotifyHandler func(n *Notification, err error)
+
+var (
+	notifyHandlersMu sync.RWMutex
+	notifyHandlers   = make(map[string]NotifyHandler)
+)
+
+// OnNotifyDone 注册某类通知发送结束时的回调
+func OnNotifyDone(kind string, handler NotifyHandler) {
+	notifyHandlersMu.Lock()
+	defer notifyHandlersMu.Unlock()
+	notifyHandlers[kind] = handler
+}
+
+// DefaultChannel 未指定渠道时使用的通知渠道，notify.channel 未配置时为微信客服消息
+func DefaultChannel() string {
+	if channel := global.Config.GetString("notify.channel"); channel != "" {
+		return channel
+	}
+	return ChannelWechat
+}
+
+// Enqueue 将通知放入发送队列，由后台 worker 发送
+func Enqueue(n *Notification) error {
+	if n.Channel == "" {
+		n.Channel = DefaultChannel()
+	}
+	if _, ok := getChannel(n.Channel); !ok {
+		return errors.New("unknown notify channel: " + n.Channel)
+	}
+	if n.Channel == ChannelInApp { // 站内消息已写入数据库，不需要推送
+		return nil
+	}
+	if n.ID == "" {
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return err
+		}
+		n.ID = hex.EncodeToString(id)
+	}
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	return global.Rdb.LPush(global.Rctx, notifyQueueKey, data).Err()
+}
+
+// RunNotifyWorkers 启动发送通知的 worker 和重试调度，notify.workers 为 worker 数量
+func RunNotifyWorkers() {
+	workers := global.Config.GetInt("notify.workers")
+	if workers <= 0 {
+		workers = 4
+	}
+	for i := 0; i < workers; i++ {
+		go runNotifyWorker()
+	}
+	runNotifyScheduler()
+}
+
+func runNotifyWorker() {
+	for {
+		result, err := global.Rdb.BRPop(global.Rctx, 5*time.Second, notifyQueueKey).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		} else if err != nil {
+			log.Println(err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		var n Notification
+		if err := json.Unmarshal([]byte(result[1]), &n); err != nil {
+			log.Println("通知格式错误:", err)
+			continue
+		}
+		dispatch(&n)
+	}
+}
+
+// runNotifyScheduler 每秒将到期的重试通知放回发送队列
+func runNotifyScheduler() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		due, err := global.Rdb.ZRangeByScore(global.Rctx, notifyRetryKey, &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+			Count: 500,
+		}).Result()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		for _, data := range due {
+			// 多个实例同时调度时只有删除成功的实例放回队列
+			if removed, err := global.Rdb.ZRem(global.Rctx, notifyRetryKey, data).Result(); err != nil || removed == 0 {
+				continue
+			}
+			global.Rdb.LPush(global.Rctx, notifyQueueKey, data)
+		}
+	}
+}
+
+// dispatch 发送一条通知，失败时按指数退避重试，超过重试次数后放入死信
+func dispatch(n *Notification) {
+	channel, ok := getChannel(n.Channel)
+	if !ok {
+		deadLetter(n, errors.New("unknown notify channel: "+n.Channel))
+		return
+	}
+
+	// 超过渠道每秒发送上限时推迟到下一秒，不计入重试次数
+	if !allowNotify(channel.Name()) {
+		schedule(n, time.Now().Truncate(time.Second).Add(time.Second))
+		return
+	}
+
+	n.Attempts++
+	err := channel.Send(n)
+	if err == nil {
+		notifyDone(n, nil)
+		return
+	}
+
+	n.LastError = err.Error()
+	var permanent *PermanentError
+	if errors.As(err, &permanent) || n.Attempts >= notifyMaxAttempts() {
+		deadLetter(n, err)
+		return
+	}
+	schedule(n, time.Now().Add(notifyBackoff(n.Attempts)))
+}
+
+func schedule(n *Notification, at time.Time) {
+	data, err := json.Marshal(n)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	err = global.Rdb.ZAdd(global.Rctx, notifyRetryKey, redis.Z{Score: float64(at.UnixMilli()), Member: data}).Err()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func deadLetter(n *Notification, err error) {
+	log.Printf("通知发送失败 %s %s: %v\n", n.Channel, n.ID, err)
+	if data, marshalErr := json.Marshal(n); marshalErr == nil {
+		pipe := global.Rdb.TxPipeline()
+		pipe.LPush(global.Rctx, notifyDeadKey, data)
+		pipe.LTrim(global.Rctx, notifyDeadKey, 0, notifyDeadLimit-1)
+		if _, err := pipe.Exec(global.Rctx); err != nil {
+			log.Println(err)
+		}
+	}
+	notifyDone(n, err)
+}
+
+func notifyDone(n *Notification, err error) {
+	if n.Kind == "" {
+		return
+	}
+	notifyHandlersMu.RLock()
+	handler, ok := notifyHandlers[n.Kind]
+	notifyHandlersMu.RUnlock()
+	if ok {
+		handler(n, err)
+	}
+}
+
+// allowNotify 按 notify.rate.<渠道> 限制每秒发送数量，未配置时不限制
+func allowNotify(channel string) bool {
+	limit := global.Config.GetInt64("notify.rate." + channel)
+	if limit <= 0 {
+		return true
+	}
+	key := notifyRateKey + channel + ":" + strconv.FormatInt(time.Now().Unix(), 10)
+	pipe := global.Rdb.TxPipeline()
+	count := pipe.Incr(global.Rctx, key)
+	pipe.Expire(global.Rctx, key, 2*time.Second)
+	if _, err := pipe.Exec(global.Rctx); err != nil {
+		log.Println(err)
+		return true
+	}
+	return count.Val() <= limit
+}
+
+func notifyMaxAttempts() int {
+	if n := global.Config.GetInt("notify.maxAttempts"); n > 0 {
+		return n
+	}
+	return 5
+}
+
+// notifyBackoff 第 attempts 次失败后等待的时间，从 notify.retryDelay 秒开始每次翻倍
+func notifyBackoff(attempts int) time.Duration {
+	delay := global.Config.GetInt("notify.retryDelay")
+	if delay <= 0 {
+		delay = 5
+	}
+	return time.Duration(delay) * time.Second << (attempts - 1)
+}
+
+// GetDeadNotifications 获取最近进入死信的通知
+func GetDeadNotifications(limit int64) ([]Notification, error) {
+	list, err := global.Rdb.LRange(global.Rctx, notifyDeadKey, 0, limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	notifications := make([]Notification, 0, len(list))
+	for _, data := range list {
+		var n Notification
+		if err := json.Unmarshal([]byte(data), &n); err == nil {
+			notifications = append(notifications, n)
+		}
+	}
+	return notifications, nil
+}
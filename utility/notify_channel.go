@@ -0,0 +1,201 @@
+package utility
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"walk-server/global"
+	"walk-server/model"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/tidwall/gjson"
+)
+
+// 通知渠道名称，与配置文件中 notify.channel 和 notify.rate 的键对应
+const (
+	ChannelWechat         = "wechat"          // 公众号客服消息
+	ChannelWechatTemplate = "wechat_template" // 公众号模板消息
+	ChannelSMS            = "sms"             // 短信
+	ChannelInApp          = "inapp"           // 只保存站内消息
+)
+
+// Channel 通知渠道，返回 PermanentError 时不再重试
+type Channel interface {
+	Name() string
+	Send(n *Notification) error
+}
+
+// PermanentError 重试也无法成功的错误，例如用户取消关注
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	channelsMu sync.RWMutex
+	channels   = map[string]Channel{
+		ChannelWechat:         wechatChannel{},
+		ChannelWechatTemplate: wechatTemplateChannel{},
+		ChannelSMS:            smsChannel{},
+		ChannelInApp:          inAppChannel{},
+	}
+)
+
+// RegisterChannel 注册或替换通知渠道
+func RegisterChannel(channel Channel) {
+	channelsMu.Lock()
+	defer channelsMu.Unlock()
+	channels[channel.Name()] = channel
+}
+
+func getChannel(name string) (Channel, bool) {
+	channelsMu.RLock()
+	defer channelsMu.RUnlock()
+	channel, ok := channels[name]
+	return channel, ok
+}
+
+// 所有渠道共用一个 HTTP 客户端
+var notifyClient = resty.New().SetTimeout(10 * time.Second)
+
+// 微信接口返回这些错误码时重试没有意义
+var wechatPermanentCodes = map[int64]bool{
+	40003: true, // open ID 不正确
+	43004: true, // 用户未关注公众号
+	43101: true, // 用户拒绝接收消息
+	45015: true, // 超过 48 小时未与公众号互动
+	45047: true, // 客服消息超过条数限制
+}
+
+// postWechat 调用需要 access token 的微信接口
+func postWechat(url string, body map[string]interface{}) error {
+	accessToken, err := GetAccessToken(global.Config.GetString("server.wechatAPPID"), global.Config.GetString("server.wechatSecret"))
+	if err != nil {
+		return err
+	}
+
+	resp, err := notifyClient.R().SetBody(body).Post(url + "?access_token=" + accessToken)
+	if IsDebugMode() {
+		fmt.Println(string(resp.Body()))
+		fmt.Println(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 微信接口出错时 errcode 不为 0
+	if code := gjson.GetBytes(resp.Body(), "errcode").Int(); code != 0 {
+		err := fmt.Errorf("wechat errcode %d: %s", code, gjson.GetBytes(resp.Body(), "errmsg").String())
+		if wechatPermanentCodes[code] {
+			return &PermanentError{Err: err}
+		}
+		return err
+	}
+	return nil
+}
+
+// decryptOpenID 解密 open ID
+func decryptOpenID(encOpenID string) string {
+	return AesDecrypt(encOpenID, global.Config.GetString("server.AESSecret"))
+}
+
+type wechatChannel struct{}
+
+func (wechatChannel) Name() string { return ChannelWechat }
+
+func (wechatChannel) Send(n *Notification) error {
+	return postWechat("https://api.weixin.qq.com/cgi-bin/message/custom/send", map[string]interface{}{
+		"touser":  decryptOpenID(n.Receiver),
+		"msgtype": "text",
+		"text": map[string]interface{}{
+			"content": n.Message + "\n---\n因为微信的限制，请回复'收到'以确保后续消息的正常接收",
+		},
+	})
+}
+
+// wechatTemplateChannel 模板消息不受 48 小时互动的限制，模板 ID 和内容字段在 notify.wechatTemplate 中配置
+type wechatTemplateChannel struct{}
+
+func (wechatTemplateChannel) Name() string { return ChannelWechatTemplate }
+
+func (wechatTemplateChannel) Send(n *Notification) error {
+	templateID := global.Config.GetString("notify.wechatTemplate.templateID")
+	if templateID == "" {
+		return &PermanentError{Err: errors.New("notify.wechatTemplate.templateID is not configured")}
+	}
+	field := global.Config.GetString("notify.wechatTemplate.field")
+	if field == "" {
+		field = "content"
+	}
+
+	data := map[string]interface{}{
+		field: map[string]string{"value": n.Message},
+	}
+	for key, value := range n.Data {
+		data[key] = map[string]string{"value": value}
+	}
+	body := map[string]interface{}{
+		"touser":      decryptOpenID(n.Receiver),
+		"template_id": templateID,
+		"data":        data,
+	}
+	if url := global.Config.GetString("notify.wechatTemplate.url"); url != "" {
+		body["url"] = url
+	}
+	return postWechat("https://api.weixin.qq.com/cgi-bin/message/template/send", body)
+}
+
+// smsChannel 通过短信服务商的 HTTP 接口发送，接口地址和密钥在 notify.sms 中配置
+// 请求体为 {"phone": 手机号, "content": 内容}，服务商返回非 2xx 状态码时视为失败
+type smsChannel struct{}
+
+func (smsChannel) Name() string { return ChannelSMS }
+
+func (smsChannel) Send(n *Notification) error {
+	url := global.Config.GetString("notify.sms.url")
+	if url == "" {
+		return &PermanentError{Err: errors.New("notify.sms.url is not configured")}
+	}
+
+	person, err := model.GetPerson(n.Receiver)
+	if err != nil {
+		return &PermanentError{Err: err}
+	}
+	if person.Tel == "" {
+		return &PermanentError{Err: errors.New("receiver has no phone number")}
+	}
+
+	resp, err := notifyClient.R().
+		SetHeader("Authorization", "Bearer "+global.Config.GetString("notify.sms.key")).
+		SetBody(map[string]string{
+			"phone":   person.Tel,
+			"content": global.Config.GetString("notify.sms.sign") + n.Message,
+		}).
+		Post(url)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		err := fmt.Errorf("sms provider status %d: %s", resp.StatusCode(), resp.String())
+		if resp.StatusCode() < 500 && resp.StatusCode() != 429 {
+			return &PermanentError{Err: err}
+		}
+		return err
+	}
+	return nil
+}
+
+// inAppChannel 只保存站内消息，不推送
+type inAppChannel struct{}
+
+func (inAppChannel) Name() string { return ChannelInApp }
+
+func (inAppChannel) Send(*Notification) error { return nil }
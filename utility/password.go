@@ -0,0 +1,33 @@
+package utility
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyPasswordHash 账号不存在时用于比较的哈希，使其与账号存在时一样花费一次 bcrypt 比较，
+// 成本与 HashPassword 一致，不对应任何可登录的密码
+const dummyPasswordHash = "$2a$10$PVLuSLITrbXFaW0prKSG3eCxY8Snh8eYOfoscAeMHDhXdeLQI/KOi"
+
+// HashPassword 使用 bcrypt 生成加盐的密码哈希
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// CheckPassword 校验密码是否与哈希匹配，比较过程是常数时间的
+func CheckPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// CheckDummyPassword 账号不存在时调用，耗时与 CheckPassword 相同，结果总是 false
+func CheckDummyPassword(password string) bool {
+	CheckPassword(dummyPasswordHash, password)
+	return false
+}
+
+// IsPasswordHashed 判断保存的密码是否已经是 bcrypt 哈希
+func IsPasswordHashed(password string) bool {
+	return strings.HasPrefix(password, "$2")
+}
@@ -0,0 +1,42 @@
+package utility
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 账号不存在时的比较需要和真实密码一样耗时，哈希必须有效且成本相同
+func TestDummyPasswordHash(t *testing.T) {
+	cost, err := bcrypt.Cost([]byte(dummyPasswordHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("dummy hash cost = %d, want %d", cost, bcrypt.DefaultCost)
+	}
+	if CheckDummyPassword("") || CheckDummyPassword("walk-server") {
+		t.Error("CheckDummyPassword returned true")
+	}
+}
+
+func TestIsPasswordHashed(t *testing.T) {
+	hash, err := HashPassword("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{hash, true},
+		{dummyPasswordHash, true},
+		{"123456", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsPasswordHashed(tt.password); got != tt.want {
+			t.Errorf("IsPasswordHashed(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
@@ -3,193 +3,223 @@ package utility
 import (
 	"bytes"
 	"errors"
-	"fmt"
-	"github.com/goccy/go-json"
-	"io"
-	"net/http"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"walk-server/global"
+
+	_ "image/jpeg"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
 )
 
-type texts struct {
-	X           int     `json:"x"`
-	Y           int     `json:"y"`
-	Text        string  `json:"text"`
-	Width       int     `json:"width"`
-	Font        string  `json:"font"`
-	FontSize    int     `json:"fontSize"`
-	LineHeight  int     `json:"lineHeight"`
-	LineSpacing float32 `json:"lineSpacing"`
-	Color       string  `json:"color"`
-	TextAlign   string  `json:"textAlign"`
-	ZIndex      int     `json:"zIndex"`
-}
-type images struct {
-	X      int    `json:"x"`
-	Y      int    `json:"y"`
-	Url    string `json:"url"`
-	Width  int    `json:"width"`
-	Height int    `json:"height"`
-	ZIndex int    `json:"zIndex"`
-}
-type lines struct {
-	StartX int    `json:"startX"`
-	StartY int    `json:"startY"`
-	EndX   int    `json:"endX"`
-	EndY   int    `json:"endY"`
-	Width  int    `json:"width"`
-	Color  string `json:"color"`
-	ZIndex int    `json:"zIndex"`
-}
-type qrcodes struct {
-	X               int    `json:"x"`
-	Y               int    `json:"y"`
-	Size            int    `json:"size"`
-	Content         string `json:"content"`
-	ForegroundColor string `json:"foregroundColor"`
-	BackgroundColor string `json:"backgroundColor"`
-	ZIndex          int    `json:"zIndex"`
-}
-type blocks struct {
-	X               int    `json:"x"`
-	Y               int    `json:"y"`
-	Width           int    `json:"width"`
-	Height          int    `json:"height"`
-	BackgroundColor string `json:"backgroundColor"`
-	ZIndex          int    `json:"zIndex"`
-}
-type Send struct {
-	Width           int       `json:"width"`
-	Height          int       `json:"height"`
-	BackgroundColor string    `json:"backgroundColor"`
-	Texts           []texts   `json:"texts"`
-	Images          []images  `json:"images"`
-	Lines           []lines   `json:"lines"`
-	Qrcodes         []qrcodes `json:"qrcodes"`
-	Blocks          []blocks  `json:"blocks"`
+// 海报尺寸，与背景模板一致
+const (
+	posterWidth  = 767
+	posterHeight = 1085
+)
+
+// PosterData 海报内容
+type PosterData struct {
+	Template string   // 背景模板名称，对应模板目录中的 <Template>.jpg（背景）和 <Template>.png（前景，可选）
+	Route    string   // 路线名称，用于选择成员名字的颜色
+	TeamName string   // 队伍名称
+	Slogan   string   // 队伍标语
+	Members  []string // 成员姓名，第一个为队长
 }
-type ReqData struct {
-	Url      string `json:"url"`
-	ExpireAt string `json:"expireAt"`
+
+// posterText 海报上的一段文字，X 为对齐的位置（左对齐时为左边，居中时为中心，右对齐时为右边），Y 为第一行的顶部
+type posterText struct {
+	X           int
+	Y           int
+	Text        string
+	FontSize    float64
+	LineSpacing float64
+	Color       color.Color
+	Align       string
 }
-type Req struct {
-	Code    int     `json:"code"`
-	Message string  `json:"message"`
-	Data    ReqData `json:"data"`
+
+var ErrPosterFont = errors.New("海报字体未加载")
+
+// posterFont 启动时由 LoadPosterFont 加载
+var posterFont *opentype.Font
+
+// DefaultPosterFontPath 未配置 poster.fontPath 时使用的海报字体路径
+const DefaultPosterFontPath = "./static/fonts/poster.ttf"
+
+// LoadPosterFont 加载 poster.fontPath 配置的字体（默认为 DefaultPosterFontPath），
+// 字体不存在、无法解析或不包含中文字形时返回错误，此时 RenderPoster 返回 ErrPosterFont，启动时调用
+func LoadPosterFont() error {
+	path := global.Config.GetString("poster.fontPath")
+	if path == "" {
+		path = DefaultPosterFontPath
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	// 海报上的队伍名称和成员姓名都是中文，字体必须包含中文字形
+	var buf sfnt.Buffer
+	if index, err := f.GlyphIndex(&buf, '中'); err != nil || index == 0 {
+		return errors.New("海报字体 " + path + " 不包含中文字形")
+	}
+	posterFont = f
+	return nil
 }
 
-func Poster(Route string, TeamName string, TeamSlogan string, CountOfMember int, TeamMember []string) (string, error) {
-	var ItemCount int
-	var PicUrl string
-	ItemCount = 0
-	PicUrl = "https://walk.ximo210.top/poster/"
-	sendB := Send{
-		Width:           767,
-		Height:          1085,
-		BackgroundColor: "#ffffff",
-	}
-	sendB.Images = append(sendB.Images, images{
-		X:      0,
-		Y:      0,
-		Url:    PicUrl + Route + ".jpg",
-		Width:  767,
-		Height: 1085,
-		ZIndex: ItemCount,
-	})
-	ItemCount++
-	var NameString string
-	for i := 0; i < CountOfMember; i++ {
-		NameString += TeamMember[i] + "\n"
+// posterTemplateDir 背景模板所在的目录
+func posterTemplateDir() string {
+	if dir := global.Config.GetString("poster.templateDir"); dir != "" {
+		return dir
 	}
-	var Color string
-	if strings.Contains(Route, "朝晖") {
-		Color = "#331B14"
+	return "./static/poster"
+}
+
+// RenderPoster 在本地生成海报，返回 PNG 数据
+func RenderPoster(data PosterData) ([]byte, error) {
+	if posterFont == nil {
+		return nil, ErrPosterFont
 	}
-	if strings.Contains(Route, "屏峰") {
-		Color = "#1C1C42"
+	canvas := image.NewRGBA(image.Rect(0, 0, posterWidth, posterHeight))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+
+	templateDir := posterTemplateDir()
+	background, err := loadPosterImage(filepath.Join(templateDir, data.Template+".jpg"))
+	if err != nil {
+		// 缺少背景模板时在白色背景上生成，不影响使用
+		log.Println("海报背景加载失败:", err)
+	} else {
+		draw.Draw(canvas, canvas.Bounds(), background, background.Bounds().Min, draw.Over)
 	}
-	if strings.Contains(Route, "莫干山") {
-		Color = "#243A24"
+
+	var memberColor color.Color = color.Black
+	switch {
+	case strings.Contains(data.Route, "朝晖"):
+		memberColor = parseHexColor("#331B14")
+	case strings.Contains(data.Route, "屏峰"):
+		memberColor = parseHexColor("#1C1C42")
+	case strings.Contains(data.Route, "莫干山"):
+		memberColor = parseHexColor("#243A24")
 	}
-	sendB.Texts = append(sendB.Texts, texts{
-		X:           383,
+	if err := drawPosterText(canvas, posterText{
+		X:           posterWidth / 2,
 		Y:           300,
-		Text:        NameString,
-		Width:       767,
-		Font:        "Alibaba-PuHuiTi-Heavy",
+		Text:        strings.Join(data.Members, "\n"),
 		FontSize:    90,
-		LineHeight:  90,
 		LineSpacing: 1.2,
-		Color:       Color,
-		TextAlign:   "center",
-		ZIndex:      ItemCount,
-	})
-	ItemCount++
-	sendB.Images = append(sendB.Images, images{
-		X:      0,
-		Y:      0,
-		Url:    PicUrl + Route + ".png",
-		Width:  767,
-		Height: 1085,
-		ZIndex: ItemCount,
-	})
-	ItemCount++
-	sendB.Texts = append(sendB.Texts, texts{
-		X:           30,
-		Y:           15,
-		Text:        TeamName,
-		Width:       767,
-		Font:        "Alibaba-PuHuiTi-Heavy",
-		FontSize:    70,
-		LineHeight:  70,
-		LineSpacing: 1,
-		Color:       "#FFFFFF",
-		TextAlign:   "left",
-		ZIndex:      ItemCount,
-	})
-	ItemCount++
-	sendB.Texts = append(sendB.Texts, texts{
-		X:           740,
-		Y:           100,
-		Text:        TeamSlogan,
-		Width:       767,
-		Font:        "Alibaba-PuHuiTi-Heavy",
-		FontSize:    25,
-		LineHeight:  25,
-		LineSpacing: 1,
-		Color:       "#DDDDDD",
-		TextAlign:   "right",
-		ZIndex:      ItemCount,
-	})
-	ItemCount++
-	sending, err := json.Marshal(sendB)
+		Color:       memberColor,
+		Align:       "center",
+	}); err != nil {
+		return nil, err
+	}
+
+	// 前景模板覆盖在成员名字之上
+	foreground, err := loadPosterImage(filepath.Join(templateDir, data.Template+".png"))
+	if err == nil {
+		draw.Draw(canvas, canvas.Bounds(), foreground, foreground.Bounds().Min, draw.Over)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		log.Println("海报前景加载失败:", err)
+	}
+
+	texts := []posterText{
+		{X: 30, Y: 15, Text: data.TeamName, FontSize: 70, LineSpacing: 1, Color: color.White, Align: "left"},
+		{X: 740, Y: 100, Text: data.Slogan, FontSize: 25, LineSpacing: 1, Color: parseHexColor("#DDDDDD"), Align: "right"},
+	}
+	for _, text := range texts {
+		if err := drawPosterText(canvas, text); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SavePoster 生成海报并保存到 ./file/poster 目录，返回有时效的访问链接
+func SavePoster(data PosterData, fileName string) (string, error) {
+	img, err := RenderPoster(data)
 	if err != nil {
 		return "", err
 	}
 
-	reader := bytes.NewReader(sending)
-	request, _ := http.NewRequest("POST", "https://api.imgrender.cn/open/v1/pics", reader)
-	request.Header.Set("Content-Type", "application/json; charset=utf-8")
-	request.Header.Set("X-API-Key", "171123855488848778.iuHQWhIvOjJqIVmKDSvXj1pWfegMoePk")
-	client := &http.Client{}
-	response, err := client.Do(request)
-	if err != nil {
+	filePath := FileDir + "poster/"
+	if err := ensureDirExists(filePath); err != nil {
 		return "", err
 	}
-	defer response.Body.Close()
-	body, _ := io.ReadAll(response.Body)
+	fullPath := filepath.Join(filePath, fileName+".png")
+	if err := os.WriteFile(fullPath, img, 0640); err != nil {
+		return "", err
+	}
+
+	return SignFileURL(fullPath)
+}
 
-	var dReq Req
-	err = json.Unmarshal(body, &dReq)
+func loadPosterImage(path string) (image.Image, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	return img, err
+}
+
+// drawPosterText 按对齐方式逐行绘制文字
+func drawPosterText(canvas draw.Image, text posterText) error {
+	face, err := opentype.NewFace(posterFont, &opentype.FaceOptions{
+		Size:    text.FontSize,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return err
 	}
+	defer face.Close()
 
-	if IsDebugMode() {
-		fmt.Println(dReq)
+	drawer := font.Drawer{
+		Dst:  canvas,
+		Src:  image.NewUniform(text.Color),
+		Face: face,
 	}
-	if dReq.Code != 0 {
-		return "", errors.New(dReq.Message)
-	} else {
-		return dReq.Data.Url, nil
+	ascent := face.Metrics().Ascent
+	lineHeight := fixed.I(int(text.FontSize * text.LineSpacing))
+	for i, line := range strings.Split(text.Text, "\n") {
+		x := fixed.I(text.X)
+		switch text.Align {
+		case "center":
+			x -= drawer.MeasureString(line) / 2
+		case "right":
+			x -= drawer.MeasureString(line)
+		}
+		drawer.Dot = fixed.Point26_6{X: x, Y: fixed.I(text.Y) + ascent + lineHeight*fixed.Int26_6(i)}
+		drawer.DrawString(line)
+	}
+	return nil
+}
+
+// parseHexColor 解析 #RRGGBB 格式的颜色，格式错误时返回黑色
+func parseHexColor(s string) color.Color {
+	value, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || len(s) != 7 {
+		return color.Black
 	}
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xff}
 }
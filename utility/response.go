@@ -3,6 +3,9 @@ package utility
 import "github.com/gin-gonic/gin"
 
 func ResponseData(context *gin.Context, statusCode int, msg string, data gin.H) {
+	// 记录返回结果，供调用记录使用
+	context.Set("response_code", statusCode)
+	context.Set("response_msg", msg)
 	context.JSON(statusCode, gin.H{
 		"code": statusCode,
 		"msg":  msg,